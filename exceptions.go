@@ -1,47 +1,123 @@
 package main
 
 import (
+	"fmt"
+	"strings"
+
 	"github.com/pterm/pterm"
-)
 
-func startException() {
-	pterm.Error.Println("Не найдена точка входа ASL")
-}
+	"statelint/localization"
 
-func emptyStates() {
-	pterm.Error.Println("Не найдена стурктура конечных автоматов")
-}
-
-func emptyProtoBody() {
-	pterm.Error.Println("Отсутсвует структура proto3 файла")
-}
-func notFindServiceBody() {
-	pterm.Error.Println("Не найдены указатели на grpc route")
+	"aws-linter/protoflow"
+)
 
+// printIssues выводит количество найденных ошибок и сами ошибки
+func printIssues(issues []protoflow.Issue) {
+	if len(issues) == 0 {
+		return
+	}
+	pterm.Error.Println(localize("ProblemsCount", len(issues)))
+	for _, issue := range issues {
+		pterm.Error.Println(issueText(issue))
+	}
 }
 
-func rpcMethodDoesNotExist() {
-	pterm.Error.Println("Не найден задекларированый тип задачи в proto3 файле")
+// issueText возвращает текст ошибки вместе с происхождением значения и путём по состояниям
+func issueText(issue protoflow.Issue) string {
+	text := issueMessage(issue)
+	if issue.Origin.Kind != "" && issue.Code != protoflow.FieldTypeMismatch {
+		text = localize("IssueOrigin", text, originText(issue.Origin))
+	}
+	if len(issue.Path) == 0 {
+		return text
+	}
+	return localize("IssuePath", text, formatPath(issue.Path))
 }
 
-func rpcRequestMessageTypeDoesNotExist(rpcName string) {
-	pterm.Error.Println("Не найден тип структуры сообщения для запроса", rpcName)
+// issueMessage возвращает текст ошибки на языке из --localization
+func issueMessage(issue protoflow.Issue) string {
+	switch issue.Code {
+	case protoflow.StartAtMissing, protoflow.StatesMissing, protoflow.ServicesMissing:
+		return localize("Issue" + string(issue.Code))
+	case protoflow.InputTypeNotMessage:
+		return localize("IssueInputTypeNotMessage", issue.MessageType)
+	case protoflow.StateNotFound:
+		if issue.State == "" {
+			return localize("IssueStartAtNotFound", issue.Field)
+		}
+		return localize("IssueStateNotFound", issue.State, issue.Field)
+	case protoflow.TransitionMissing:
+		if issue.Attribute != "Next" {
+			return localize("IssueCatcherTransitionMissing", issue.Attribute, issue.State)
+		}
+		return localize("IssueTransitionMissing", issue.State)
+	case protoflow.StateTypeUnsupported:
+		return localize("IssueStateTypeUnsupported", issue.Actual, issue.State)
+	case protoflow.NestedMachineInvalid:
+		return localize("IssueNestedMachineInvalid", issue.Attribute, issue.State)
+	case protoflow.TooManyInputs:
+		return localize("IssueTooManyInputs", issue.State)
+	case protoflow.ResourceInvalid:
+		return localize("IssueResourceInvalid", issue.State, issue.Actual, issue.Reason)
+	case protoflow.ServiceNotFound:
+		return localize("IssueServiceNotFound", issue.MessageType)
+	case protoflow.RPCNotFound:
+		return localize("IssueRPCNotFound", issue.Field, issue.MessageType)
+	case protoflow.MessageNotFound:
+		return localize("IssueMessageNotFound", issue.MessageType)
+	case protoflow.AmbiguousReference:
+		return localize("IssueAmbiguousReference", issue.MessageType, strings.Join(issue.Candidates, ", "))
+	case protoflow.InputNotObject:
+		return localize("IssueInputNotObject", issue.State, issue.Actual)
+	case protoflow.FieldMissing:
+		return localize("IssueFieldMissing", issue.Field, issue.MessageType)
+	case protoflow.OneofMissing:
+		return localize("IssueOneofMissing", issue.Field, strings.Join(issue.Candidates, ", "), issue.MessageType)
+	case protoflow.FieldTypeMismatch:
+		if issue.Origin.Kind != "" {
+			return localize("IssueFieldTypeMismatchOrigin", issue.Field, issue.Actual, originText(issue.Origin),
+				issue.MessageType, issue.Field, issue.Expected)
+		}
+		return localize("IssueFieldTypeMismatch", issue.Field, issue.Actual, issue.Expected, issue.MessageType)
+	case protoflow.PathUnsupported:
+		return localize("IssuePathUnsupported", issue.Attribute, issue.JSONPath, issue.State, issue.Reason)
+	case protoflow.PathNotFound:
+		return localize("IssuePathNotFound", issue.Attribute, issue.JSONPath, issue.State, issue.Field, issue.Actual)
+	case protoflow.ItemsNotArray:
+		return localize("IssueItemsNotArray", issue.JSONPath, issue.State, issue.Actual)
+	}
+	return string(issue.Code)
 }
 
-func rpcResponseMessageTypeDoesNotExist(rpcName string) {
-	pterm.Error.Println("Не найден тип структуры сообщения для ответа", rpcName)
+// originText описывает, откуда взялось значение, например "получено из Task1 → Task1Response.id на шаге 2"
+func originText(origin protoflow.Origin) string {
+	switch origin.Kind {
+	case protoflow.OriginInput:
+		return localize("OriginInput", joinField(origin.Message, origin.Field))
+	case protoflow.OriginResponse:
+		return localize("OriginResponse", origin.State, joinField(origin.Message, origin.Field), origin.Step)
+	case protoflow.OriginPlaybook:
+		return localize("OriginPlaybook", joinField(origin.Attribute, origin.Field), origin.State, origin.Step)
+	case protoflow.OriginError:
+		return localize("OriginError", joinField(origin.Attribute, origin.Field), origin.State, origin.Step)
+	}
+	return string(origin.Kind)
 }
 
-func messageBodyDoesNotExist(messageStructName string) {
-	pterm.Error.Println("Не найдено тип структуры сообщения", messageStructName, "в proto3 файле")
+// joinField добавляет к имени путь поля внутри него
+func joinField(name string, field string) string {
+	if field == "" {
+		return name
+	}
+	return name + "." + field
 }
 
-func fieldDoesNotExist(field string, messageType string) {
-	pterm.Error.Println("В переданной структуре нет необходимого поля:", field+".", "MessageType:", messageType)
+// localize подставляет args в строку name из файла локализации
+func localize(name string, args ...interface{}) string {
+	return fmt.Sprintf(localization.GetLocalizerOrPanic().GetString(name), args...)
 }
 
-func invalidFieldType(field string, actual string, expected string, messageType string) {
-	pterm.Error.Println(
-		"Не соотвествие типов для поля:", field+". Передан тип:",
-		actual+". Ожидается:", expected+".", "MessageType:", messageType)
+// formatPath собирает путь по состояниям в строку вида "Task0 → Choice0 → Task1"
+func formatPath(path []string) string {
+	return strings.Join(path, " → ")
 }
@@ -4,15 +4,23 @@ go 1.17
 
 require (
 	github.com/pterm/pterm v0.12.33
+	github.com/stretchr/testify v1.7.0
 	github.com/yoheimuta/go-protoparser/v4 v4.5.0
+	google.golang.org/protobuf v1.27.1
+	statelint v0.0.0
 )
 
 require (
 	github.com/atomicgo/cursor v0.0.1 // indirect
+	github.com/davecgh/go-spew v1.1.1 // indirect
 	github.com/gookit/color v1.4.2 // indirect
 	github.com/mattn/go-runewidth v0.0.13 // indirect
+	github.com/pmezard/go-difflib v1.0.0 // indirect
 	github.com/rivo/uniseg v0.2.0 // indirect
 	github.com/xo/terminfo v0.0.0-20210125001918-ca9a967f8778 // indirect
 	golang.org/x/sys v0.0.0-20211013075003-97ac67df715c // indirect
 	golang.org/x/term v0.0.0-20210927222741-03fcf44c2211 // indirect
+	gopkg.in/yaml.v3 v3.0.0-20210107192922-496545a6307b // indirect
 )
+
+replace statelint => ./statelint
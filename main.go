@@ -1,196 +1,224 @@
 package main
 
 import (
-	"encoding/json"
+	"embed"
+	"flag"
 	"fmt"
 	"github.com/pterm/pterm"
+	"io/fs"
 	"io/ioutil"
 	"os"
-	"time"
+	"path/filepath"
+	"strings"
+
+	"statelint/localization"
+
+	"aws-linter/protoflow"
+)
+
+// Коды возврата утилиты
+const (
+	exitOK          = 0
+	exitLintFailure = 1
+	exitUsageError  = 2
+)
+
+var (
+	aslPath   = flag.String("asl", "", "path to the Amazon States Language playbook, e.g. playbooks/incident.json")
+	protoPath = flag.String("proto", "", "path to the proto3 file with the playbook services, e.g. proto/incident.proto")
+	quiet     = flag.Bool("quiet", false, "do not draw the banner and the spinner, print only found problems")
+	report    = flag.Bool("report", false, "also print response fields that no request reads, values overwritten "+
+		"before they are read and the possible execution outputs")
+
+	languageUsage = fmt.Sprintf("sets the language of the output, value should be "+
+		"name of file in folder \"%s\" without extension", langsFolder)
+	language = flag.String("localization", "ru", languageUsage)
+
+	compatibilityModeName = flag.String("type_compatibility", "lenient", "how field types are compared: "+
+		"lenient allows safe numeric widening and structurally equal messages, strict requires identical types")
+
+	inputType = flag.String("input_type", "", "full name of the proto3 message passed as the playbook input, "+
+		"e.g. example.playbook.Incident. Overrides the @input_type annotation in the playbook Comment")
+
+	descriptorSetPath = flag.String("descriptor_set_in", "", "path to a binary FileDescriptorSet used instead of "+
+		"--proto, e.g. the output of protoc -o out.pb --include_imports or buf build -o out.pb")
+
+	debug      = flag.Bool("debug", false, "debug flag to output more parsing process detail")
+	permissive = flag.Bool("permissive", true, "permissive flag to allow the permissive parsing rather than the just documented spec")
+
+	protoIncludePathsUsage = "directory to search for imported proto3 files, may be repeated. " +
+		"Defaults to the directory of the --proto file"
+	protoIncludePaths includePathsFlag
 )
 
-func parseAsl(fileName string) map[string]interface{} {
+// includePathsFlag - директории, в которых ищутся импортируемые proto3 файлы.
+// Флаг можно указать несколько раз или перечислить директории через разделитель путей, как в protoc.
+type includePathsFlag []string
+
+func (f *includePathsFlag) String() string {
+	return strings.Join(*f, string(os.PathListSeparator))
+}
+
+func (f *includePathsFlag) Set(value string) error {
+	*f = append(*f, filepath.SplitList(value)...)
+	return nil
+}
+
+func init() {
+	flag.StringVar(language, "l", "ru", languageUsage)
+	flag.Var(&protoIncludePaths, "I", protoIncludePathsUsage)
+	flag.Var(&protoIncludePaths, "proto_path", protoIncludePathsUsage)
+}
+
+func readAsl(fileName string) ([]byte, error) {
 	jsonFile, err := os.Open(fileName)
 	if err != nil {
-		pterm.Error.Println(err)
-	} else {
-		pterm.Success.Println("Successfully Opened", fileName)
+		return nil, fmt.Errorf("can not open ASL file \"%s\": %w", fileName, err)
 	}
-	byteValue, _ := ioutil.ReadAll(jsonFile)
-	var result map[string]interface{}
-	json.Unmarshal([]byte(byteValue), &result)
-
 	defer jsonFile.Close()
 
-	return result
+	byteValue, err := ioutil.ReadAll(jsonFile)
+	if err != nil {
+		return nil, fmt.Errorf("can not read ASL file \"%s\": %w", fileName, err)
+	}
+	return byteValue, nil
 }
 
 func main() {
-	startConsoleLine()
-	var amazonJsonFileName string
-	var protoFileName string
-	fmt.Print("PlayBook Name: ")
-	fmt.Scanln(&amazonJsonFileName)
-	fmt.Print("Proto3 File Name: ")
-	fmt.Scanln(&protoFileName)
-	fmt.Println()
-
-	introSpinner, _ := pterm.DefaultSpinner.WithRemoveWhenDone(true).Start("Doing something important...")
-	time.Sleep(time.Second)
-	for i := 3; i > 0; i-- {
-		if i > 1 {
-			introSpinner.UpdateText("Doing something important... ")
-		} else {
-			introSpinner.UpdateText("Doing something important... ")
-		}
-		time.Sleep(time.Second)
-	}
-	introSpinner.Stop()
-
-	amazonJsonFile := parseAsl(amazonJsonFileName + ".json")
-	startAtInterface := amazonJsonFile["StartAt"]
-	if startAtInterface == nil {
-		startException()
-		return
-	}
-	startAtString := startAtInterface.(string)
-
-	if amazonJsonFile["States"] == nil {
-		emptyStates()
-		return
-	}
-	states := amazonJsonFile["States"].(map[string]interface{})
-
-	//По сути response -> request структура
-	var steps []string
-	flag := true
-	var nextStep string
-	for {
-		if flag {
-			steps = append(steps, startAtString)
-			actualStep := states[startAtString].(map[string]interface{})
-			if actualStep["End"] != nil {
-				break
-			}
-			steps = append(steps, actualStep["Next"].(string))
-			nextStep = actualStep["Next"].(string)
-			flag = false
-			continue
-		}
-		actualStep := states[nextStep].(map[string]interface{})
-		if actualStep["End"] != nil {
-			break
-		}
-		steps = append(steps, actualStep["Next"].(string))
-		nextStep = actualStep["Next"].(string)
+	os.Exit(run())
+}
+
+func run() int {
+	flag.Usage = usage
+	flag.Parse()
+
+	// Пути можно передать и позиционными аргументами: aws-linter playbook.json playbook.proto
+	args := flag.Args()
+	if *aslPath == "" && len(args) > 0 {
+		*aslPath, args = args[0], args[1:]
+	}
+	if *protoPath == "" && *descriptorSetPath == "" && len(args) > 0 {
+		*protoPath, args = args[0], args[1:]
+	}
+	if *aslPath == "" || (*protoPath == "") == (*descriptorSetPath == "") || len(args) != 0 {
+		flag.Usage()
+		return exitUsageError
 	}
 
-	//Парсинг прото файла
-	protoStructure := protoParse(protoFileName)
-	if protoStructure == nil {
-		return
+	err := setupLocalization()
+	if err != nil {
+		pterm.Error.Println(err)
+		return exitUsageError
 	}
-	protoBodyStructure := protoStructure["ProtoBody"].([]interface{})
-	if protoBodyStructure == nil {
-		emptyProtoBody()
-		return
+
+	mode, err := protoflow.ParseCompatibility(*compatibilityModeName)
+	if err != nil {
+		pterm.Error.Println(err)
+		return exitUsageError
 	}
-	var serviceStructure map[string]interface{}
-	for _, value := range protoBodyStructure {
-		if value.(map[string]interface{})["ServiceName"] != nil {
-			serviceStructure = value.(map[string]interface{})
-		}
+
+	if !*quiet {
+		startConsoleLine()
 	}
 
-	if serviceStructure["ServiceBody"] == nil {
-		notFindServiceBody()
-		return
+	var spinner *pterm.SpinnerPrinter
+	if !*quiet {
+		spinner, _ = pterm.DefaultSpinner.WithRemoveWhenDone(true).Start("Parsing playbook and proto3 files...")
+	}
+	definition, aslErr := readAsl(*aslPath)
+	types, typesPath, protoErr := loadProtoTypes()
+	if spinner != nil {
+		spinner.Stop()
 	}
-	serviceBody := serviceStructure["ServiceBody"].([]interface{})
 
-	actualStateVariable := map[string]string{"start": "true"}
-	for _, step := range steps {
-		var rpcStepStructure map[string]interface{}
-		for _, value := range serviceBody {
-			if value.(map[string]interface{})["RPCName"] == step {
-				rpcStepStructure = value.(map[string]interface{})
+	if aslErr != nil || protoErr != nil {
+		for _, err := range []error{aslErr, protoErr} {
+			if err != nil {
+				pterm.Error.Println(err)
 			}
 		}
-		if rpcStepStructure == nil {
-			rpcMethodDoesNotExist()
-			return
-		}
-		//Обработка запроса
-		rpcRequest := rpcStepStructure["RPCRequest"].(map[string]interface{})
-		if rpcRequest == nil {
-			rpcRequestMessageTypeDoesNotExist(step)
-		}
-		rpcRequestMessageType := rpcRequest["MessageType"].(string)
+		return exitUsageError
+	}
+	if !*quiet {
+		pterm.Success.Println("Successfully Opened", *aslPath)
+		pterm.Success.Println("Successfully Opened", typesPath)
+	}
 
-		var messageRequestStructure map[string]interface{}
-		for _, value := range protoBodyStructure {
-			if value.(map[string]interface{})["MessageName"] == rpcRequestMessageType {
-				messageRequestStructure = value.(map[string]interface{})
-			}
-		}
-		if messageRequestStructure == nil {
-			messageBodyDoesNotExist(rpcRequestMessageType)
-			return
+	options := protoflow.Options{Compatibility: mode, InputType: *inputType}
+	var issues []protoflow.Issue
+	var analysis *protoflow.Analysis
+	if *report {
+		analysis, err = protoflow.Analyze(definition, types, options)
+		if analysis != nil {
+			issues = analysis.Issues
 		}
-		messageRequestBody := messageRequestStructure["MessageBody"].([]interface{})
+	} else {
+		issues, err = protoflow.Check(definition, types, options)
+	}
+	if err != nil {
+		pterm.Error.Println(fmt.Errorf("%s: %w", *aslPath, err))
+		return exitUsageError
+	}
+	printIssues(issues)
+	if analysis != nil {
+		printReport(analysis)
+	}
+	if len(issues) != 0 {
+		return exitLintFailure
+	}
 
-		//Дикт где ключ-название переменной, значение - тип переменной
-		requestVariables := map[string]string{}
-		for _, value := range messageRequestBody {
-			requestVariables[value.(map[string]interface{})["FieldName"].(string)] = value.(map[string]interface{})["Type"].(string)
-		}
-		//Проверка на соотвестивие запрашиваемых данных и данных из актуального стейта
+	if !*quiet {
+		pterm.Println()
+		pterm.Success.Println(localize("CheckSucceeded"))
+	}
+	return exitOK
+}
 
-		for key, value := range requestVariables {
-			//Нулевое вхождение
-			if actualStateVariable["start"] == "true" {
-				actualStateVariable["start"] = "false"
-				break
-			}
-			_, ok := actualStateVariable[key]
-			if !ok {
-				fieldDoesNotExist(key, rpcRequestMessageType)
-				return
-			}
-			if actualStateVariable[key] != value {
-				invalidFieldType(key, actualStateVariable[key], value, rpcRequestMessageType)
-				return
-			}
-		}
-		//Обработка ответа -> актуализирование переменных для следующего вхождения
-		rpcResponse := rpcStepStructure["RPCResponse"].(map[string]interface{})
-		if rpcResponse == nil {
-			rpcResponseMessageTypeDoesNotExist(step)
-			return
-		}
-		rpcResponseMessageType := rpcResponse["MessageType"].(string)
-		var messageResponseStructure map[string]interface{}
-		for _, value := range protoBodyStructure {
-			if value.(map[string]interface{})["MessageName"] == rpcResponseMessageType {
-				messageResponseStructure = value.(map[string]interface{})
-			}
-		}
-		if messageResponseStructure == nil {
-			messageBodyDoesNotExist(rpcResponseMessageType)
-			return
-		}
-		messageResponseBody := messageResponseStructure["MessageBody"].([]interface{})
-		responseVariables := map[string]string{}
-		for _, value := range messageResponseBody {
-			responseVariables[value.(map[string]interface{})["FieldName"].(string)] = value.(map[string]interface{})["Type"].(string)
-		}
-		//Обогощаем стейт выходными данными, если ключ уже существует перезаписываем новыми данными
-		for key, value := range responseVariables {
-			actualStateVariable[key] = value
-		}
+// langsFolder - папка с файлами локализации, которые встроены в бинарный файл
+const langsFolder = "langs"
+
+//go:embed langs/*.json
+var langFiles embed.FS
+
+// setupLocalization загружает встроенный файл локализации языка из --localization,
+// поэтому утилита не зависит от текущей директории
+func setupLocalization() error {
+	files, err := fs.Sub(langFiles, langsFolder)
+	if err != nil {
+		return fmt.Errorf("can not load localization: %w", err)
 	}
-	pterm.Println()
-	pterm.Success.Println("Все готово! Все в порядке! You are awesome :)")
+	localizer, err := localization.GetLocalizerFromFS(files)
+	if err != nil {
+		return fmt.Errorf("can not load localization: %w", err)
+	}
+	err = localizer.SetLocalization(*language)
+	if err != nil {
+		return fmt.Errorf("can not set localization \"%s\": %w", *language, err)
+	}
+	return nil
+}
+
+// loadProtoTypes загружает определения proto3 из --proto или --descriptor_set_in
+func loadProtoTypes() (protoflow.Registry, string, error) {
+	if *descriptorSetPath != "" {
+		types, err := protoflow.LoadDescriptorSet(*descriptorSetPath)
+		return types, *descriptorSetPath, err
+	}
+	types, err := protoflow.LoadProtoFiles(*protoPath, protoIncludePaths, protoflow.ParseOptions{
+		Debug:      *debug,
+		Permissive: *permissive,
+	})
+	return types, *protoPath, err
+}
+
+func usage() {
+	fmt.Fprintf(flag.CommandLine.Output(),
+		"Usage: %s [flags] [-I <include dir>...] --asl <playbook.json> --proto <playbook.proto>\n"+
+			"       %s [flags] --asl <playbook.json> --descriptor_set_in <playbook.pb>\n"+
+			"       %s [flags] <playbook.json> <playbook.proto>\n\n"+
+			"Exit codes: %d - no problems, %d - lint problems found, %d - usage or IO error\n\n",
+		os.Args[0], os.Args[0], os.Args[0], exitOK, exitLintFailure, exitUsageError)
+	flag.PrintDefaults()
 }
 
 func startConsoleLine() {
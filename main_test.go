@@ -0,0 +1,53 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const testProto = "protoflow/testdata/incident.proto"
+
+// runWith запускает утилиту с аргументами args, у остальных флагов - значения по умолчанию
+func runWith(args ...string) int {
+	defer func(args []string) { os.Args = args }(os.Args)
+
+	defaults := []string{"--asl=", "--proto=", "--descriptor_set_in=", "--input_type=", "--quiet=true",
+		"--report=false", "--type_compatibility=lenient", "--localization=en"}
+	protoIncludePaths = nil
+	os.Args = append(append([]string{"aws-linter"}, defaults...), args...)
+	return run()
+}
+
+func TestRun(t *testing.T) {
+	dir := t.TempDir()
+	playbook := filepath.Join(dir, "playbook.asl")
+	require.NoError(t, os.WriteFile(playbook, []byte(`{
+		"StartAt": "Block",
+		"States": {
+			"Block": {"Type": "Task", "Resource": "grpc:127.0.0.1:5678/example.incident.Incidents/Block", "End": true}
+		}
+	}`), 0o600))
+
+	testCases := []struct {
+		name string
+		args []string
+		code int
+	}{
+		{"no problems", []string{"--asl", playbook, "--proto", testProto}, exitOK},
+		{"positional paths", []string{playbook, testProto}, exitOK},
+		{"problems", []string{"--asl", playbook, "--proto", testProto, "--input_type", "GeoIP"}, exitLintFailure},
+		{"missing playbook", []string{"--asl", filepath.Join(dir, "missing.json"), "--proto", testProto}, exitUsageError},
+		{"missing proto", []string{"--asl", playbook}, exitUsageError},
+		{"proto and descriptor set", []string{"--descriptor_set_in", "out.pb", playbook, testProto}, exitUsageError},
+		{"extra argument", []string{playbook, testProto, "extra"}, exitUsageError},
+		{"unknown compatibility", []string{"--type_compatibility", "loose", playbook, testProto}, exitUsageError},
+	}
+
+	for _, testCase := range testCases {
+		assert.Equal(t, testCase.code, runWith(testCase.args...), testCase.name)
+	}
+}
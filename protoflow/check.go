@@ -0,0 +1,522 @@
+// Package protoflow проверяет передачу данных между состояниями плейбука Amazon States Language,
+// задачи которого вызывают gRPC методы: каждая задача должна получать на вход все поля
+// своего request сообщения совместимых типов.
+package protoflow
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// inputTypeAnnotation - объявление типа входа плейбука в его Comment,
+// например "Comment": "Реагирование на инцидент. @input_type example.playbook.Incident"
+var inputTypeAnnotation = regexp.MustCompile(`@input_type\s+(\S+)`)
+
+// flowChecker обходит граф состояний плейбука и сверяет поля, доступные на входе
+// каждой задачи, с полями request сообщения соответствующего rpc метода.
+type flowChecker struct {
+	graph         *stateGraph
+	types         Registry
+	compatibility *typeCompatibility
+	problems      *issueList
+	// usage - чтения и перезаписи значений для отчёта Analyze, nil для Check
+	usage *dataUsage
+}
+
+// Options - настройки проверки
+type Options struct {
+	// Compatibility - насколько строго сравниваются типы полей
+	Compatibility Compatibility
+	// InputType - сообщение, которое передаётся на вход плейбука. Если оно не задано,
+	// берётся из аннотации "@input_type <message>" в Comment плейбука,
+	// а без неё тип входа считается неизвестным и первая задача не проверяется по нему.
+	InputType string
+}
+
+// Check проверяет, что каждая задача плейбука definition получает на вход все поля
+// своего request сообщения из registry, и возвращает все найденные проблемы.
+// Ошибка возвращается, только если definition - не JSON объект.
+func Check(definition []byte, registry Registry, options Options) ([]Issue, error) {
+	var amazonJsonFile map[string]interface{}
+	err := json.Unmarshal(definition, &amazonJsonFile)
+	if err != nil {
+		return nil, fmt.Errorf("can not unmarshal ASL definition: %w", err)
+	}
+	problems, _ := checkDataFlow(amazonJsonFile, registry, options, nil)
+	return problems.GetIssues(), nil
+}
+
+// Analyze проверяет плейбук так же, как Check, и дополнительно отчитывается, какие поля
+// response никто не читает, какие значения перезаписываются до чтения и каким может быть выход плейбука.
+// Значения одной формы из разных задач проходят граф отдельно, поэтому проблема
+// в одном и том же поле может быть найдена для каждого из них.
+func Analyze(definition []byte, registry Registry, options Options) (*Analysis, error) {
+	var amazonJsonFile map[string]interface{}
+	err := json.Unmarshal(definition, &amazonJsonFile)
+	if err != nil {
+		return nil, fmt.Errorf("can not unmarshal ASL definition: %w", err)
+	}
+	usage := newDataUsage()
+	problems, outputs := checkDataFlow(amazonJsonFile, registry, options, usage)
+
+	analysis := &Analysis{Issues: problems.GetIssues(), Outputs: make([]string, 0, len(outputs))}
+	usage.report(analysis)
+	for _, output := range outputs {
+		analysis.Outputs = append(analysis.Outputs, output.String())
+	}
+	sort.Strings(analysis.Outputs)
+	return analysis, nil
+}
+
+// checkDataFlow обходит плейбук, собирает проблемы передачи данных и возвращает выходы плейбука.
+// Путь по графу состояний обрывается, если выход состояния нельзя вычислить
+// или если это значение уже приходило на вход следующего состояния.
+// usage, если задан, собирает чтения и перезаписи значений.
+func checkDataFlow(
+	amazonJsonFile map[string]interface{},
+	types Registry,
+	options Options,
+	usage *dataUsage,
+) (*issueList, []*stateValue) {
+	problems := newIssueList()
+
+	startAtString, _ := amazonJsonFile["StartAt"].(string)
+	if startAtString == "" {
+		problems.Append(startException())
+	}
+	states, _ := amazonJsonFile["States"].(map[string]interface{})
+	if states == nil {
+		problems.Append(emptyStates())
+	}
+	if !types.hasServices() {
+		problems.Append(notFindServiceBody())
+	}
+	if problems.Len() != 0 {
+		return problems, nil
+	}
+
+	checker := &flowChecker{
+		graph:         newStateGraph(startAtString, states, nil, problems),
+		types:         types,
+		compatibility: newTypeCompatibility(options.Compatibility, types),
+		problems:      problems,
+		usage:         usage,
+	}
+	inputType := options.InputType
+	if inputType == "" {
+		inputType = declaredInputType(amazonJsonFile)
+	}
+	input := newAnyValue()
+	if inputType != "" {
+		_, isScalar := protoScalarTypes[inputType]
+		fullName, isEnum, err := types.findType(inputType)
+		switch {
+		case isScalar || isEnum:
+			problems.Append(inputTypeIsNotMessage(inputType))
+			return problems, nil
+		case err != nil:
+			checker.reportLookupError(nil, inputType, err)
+			return problems, nil
+		}
+		input = newProtoValue(fieldType{Name: fullName}).withOrigin(Origin{Kind: OriginInput, Message: fullName})
+	}
+
+	return problems, checker.walk(input, nil)
+}
+
+// declaredInputType возвращает сообщение из аннотации @input_type в Comment плейбука
+func declaredInputType(amazonJsonFile map[string]interface{}) string {
+	comment, _ := amazonJsonFile["Comment"].(string)
+	if match := inputTypeAnnotation.FindStringSubmatch(comment); match != nil {
+		return match[1]
+	}
+	return ""
+}
+
+// transition - значение, которое состояние передаёт в state.
+// Пустой state означает, что значение - выход автомата.
+type transition struct {
+	state string
+	value *stateValue
+	path  []string
+}
+
+// walk обходит граф автомата от StartAt, пока на вход его состояний приходят
+// новые значения, поэтому циклы проходятся до неподвижной точки.
+// input - вход автомата, path - путь до автомата. Возвращает выходы автомата.
+func (c *flowChecker) walk(input *stateValue, path []string) []*stateValue {
+	queue := []transition{{state: c.graph.startAt, value: input, path: path}}
+	// inputs - формы значений, пришедших на вход каждого состояния, и для каждой формы
+	// их происхождения. Число форм ограничено maxStateInputs одинаково в Check и Analyze,
+	// поэтому отчёт не меняет найденные проблемы.
+	inputs := map[string]map[string]map[string]struct{}{}
+	var outputs []*stateValue
+	outputKeys := map[string]struct{}{}
+
+	for len(queue) != 0 {
+		current := queue[0]
+		queue = queue[1:]
+		node, ok := c.graph.nodes[current.state]
+		if !ok {
+			continue
+		}
+		statePath := append(current.path[:len(current.path):len(current.path)], current.state)
+
+		shapes, ok := inputs[current.state]
+		if !ok {
+			shapes = map[string]map[string]struct{}{}
+			inputs[current.state] = shapes
+		}
+		shape := current.value.String()
+		origins, ok := shapes[shape]
+		if !ok {
+			if len(shapes) == maxStateInputs {
+				c.problems.Append(tooManyStateInputs(append(c.graph.location[:len(c.graph.location):len(c.graph.location)], current.state)))
+				continue
+			}
+			origins = map[string]struct{}{}
+			shapes[shape] = origins
+		}
+		origin := ""
+		if c.usage != nil {
+			origin = current.value.originString()
+		}
+		// значение той же формы с другим происхождением нужно только отчёту Analyze,
+		// поэтому после maxStateInputs таких значений оно пропускается без проблемы
+		if _, ok := origins[origin]; ok || len(origins) == maxStateInputs {
+			continue
+		}
+		origins[origin] = struct{}{}
+
+		for _, next := range c.visit(node, current.value, statePath) {
+			if next.state != "" {
+				queue = append(queue, next)
+				continue
+			}
+			if _, ok := outputKeys[next.value.String()]; !ok {
+				outputKeys[next.value.String()] = struct{}{}
+				outputs = append(outputs, next.value)
+			}
+		}
+	}
+	return outputs
+}
+
+// visit проверяет состояние node со входом input и возвращает переходы из него.
+// path - путь до состояния, включая его само.
+func (c *flowChecker) visit(node *stateNode, input *stateValue, path []string) []transition {
+	if node.stateType == "Fail" {
+		return nil
+	}
+	effectiveInput := c.applyPath(node.step, "InputPath", input, path)
+
+	var outputs []*stateValue
+	switch node.stateType {
+	case "Choice":
+		if c.usage != nil {
+			c.readChoiceVariables(node.step["Choices"], effectiveInput, path)
+		}
+		output := c.applyPath(node.step, "OutputPath", effectiveInput, path)
+		transitions := make([]transition, 0, len(node.choices))
+		for _, next := range node.choices {
+			transitions = append(transitions, transition{state: next, value: output, path: path})
+		}
+		return transitions
+	case "Succeed":
+		return []transition{{value: c.applyPath(node.step, "OutputPath", effectiveInput, path), path: path}}
+	case "Wait":
+		outputs = []*stateValue{c.applyPath(node.step, "OutputPath", effectiveInput, path)}
+	case "Pass", "Task", "Parallel", "Map":
+		for _, result := range c.stateResults(node.stateType, node.step, effectiveInput, path) {
+			outputs = append(outputs, c.applyResult(node.step, input, result, path))
+		}
+	default:
+		c.problems.Append(unsupportedStateType(path, node.stateType))
+		return nil
+	}
+
+	transitions := c.catchTransitions(node, input, path)
+	for _, output := range outputs {
+		switch {
+		case node.end:
+			transitions = append(transitions, transition{value: output, path: path})
+		case node.next != "":
+			transitions = append(transitions, transition{state: node.next, value: output, path: path})
+		}
+	}
+	return transitions
+}
+
+// readChoiceVariables отмечает прочитанными значения, которые сравнивают правила Choice:
+// Variable и пути сравнений с суффиксом Path, в том числе внутри And, Or и Not.
+// Пути, которые не подходят ко входу, пропускаются - их проверяет statelint.
+func (c *flowChecker) readChoiceVariables(rules interface{}, input *stateValue, path []string) {
+	switch rules := rules.(type) {
+	case []interface{}:
+		for _, rule := range rules {
+			c.readChoiceVariables(rule, input, path)
+		}
+	case map[string]interface{}:
+		for key, value := range rules {
+			switch {
+			case key == "Variable" || (strings.HasSuffix(key, "Path") && key != "OutputPath" && key != "InputPath"):
+				jsonPath, _ := value.(string)
+				root, steps, err := parseJSONPath(jsonPath)
+				if err != nil || root != "$" {
+					continue
+				}
+				if selected, mismatch := c.selectPath(input, steps, root, path); mismatch == nil {
+					c.usage.read(selected)
+				}
+			case key == "And" || key == "Or" || key == "Not":
+				c.readChoiceVariables(value, input, path)
+			}
+		}
+	}
+}
+
+// catchTransitions возвращает переходы в обработчики Catch задачи, Parallel или Map.
+// Обработчик получает вход состояния, в который по его ResultPath положен
+// объект ошибки {Error, Cause}. Без ResultPath объект ошибки заменяет вход.
+func (c *flowChecker) catchTransitions(node *stateNode, input *stateValue, path []string) []transition {
+	transitions := make([]transition, 0, len(node.catchers))
+	for _, catcher := range node.catchers {
+		catcherPath := append(path[:len(path):len(path)], fmt.Sprintf("Catch[%d]", catcher.index))
+		origin := stateOrigin(OriginError, path)
+		origin.Attribute = catcherPath[len(catcherPath)-1]
+		errorOutput := newObjectValue(map[string]*stateValue{
+			"Error": newProtoValue(fieldType{Name: "string"}).withOrigin(origin.field("Error")),
+			"Cause": newProtoValue(fieldType{Name: "string"}).withOrigin(origin.field("Cause")),
+		}).withOrigin(origin)
+		transitions = append(transitions, transition{
+			state: catcher.next,
+			value: c.applyResultPath(catcher.step, input, errorOutput, catcherPath),
+			path:  catcherPath,
+		})
+	}
+	return transitions
+}
+
+// walkParallel проходит по каждой ветке Branches с одним и тем же входным значением.
+// Выход Parallel - массив из выходов веток в том порядке, в котором они заданы.
+// Если выход хотя бы одной ветки неизвестен, неизвестен и выход Parallel.
+// Если сочетаний выходов веток больше maxStateInputs, путь обрывается так же, как в walk.
+func (c *flowChecker) walkParallel(parallelStep map[string]interface{}, input *stateValue, path []string) []*stateValue {
+	branches, _ := parallelStep["Branches"].([]interface{})
+
+	complete := true
+	branchOutputs := make([][]*stateValue, 0, len(branches))
+	for i, value := range branches {
+		branch, _ := value.(map[string]interface{})
+		branchPath := append(path[:len(path):len(path)], fmt.Sprintf("Branches[%d]", i))
+
+		startAt, _ := branch["StartAt"].(string)
+		states, _ := branch["States"].(map[string]interface{})
+		if startAt == "" || states == nil {
+			c.problems.Append(invalidParallelBranch(path, i))
+			complete = false
+			continue
+		}
+
+		location := c.graph.nestedLocation(path[len(path)-1], fmt.Sprintf("Branches[%d]", i))
+		outputs := c.nested(startAt, states, location).walk(input, branchPath)
+		if len(outputs) == 0 {
+			complete = false
+		}
+		branchOutputs = append(branchOutputs, outputs)
+	}
+	if !complete {
+		return nil
+	}
+	if countCombinations(branchOutputs) > maxStateInputs {
+		c.problems.Append(tooManyStateInputs(append(c.graph.location[:len(c.graph.location):len(c.graph.location)], path[len(path)-1])))
+		return nil
+	}
+	return combineBranchOutputs(branchOutputs)
+}
+
+// walkMap проходит Iterator для каждого возможного типа элемента из ItemsPath.
+// Вход итерации - элемент или значение ItemSelector, в котором элемент доступен
+// как $$.Map.Item.Value. Выход Map - массив из выходов итераций.
+func (c *flowChecker) walkMap(mapStep map[string]interface{}, input *stateValue, path []string) []*stateValue {
+	itemsPath, ok := mapStep["ItemsPath"].(string)
+	if !ok {
+		itemsPath = "$"
+	}
+	items := c.resolveItems(itemsPath, input, path)
+
+	iteratorStep, _ := mapStep["Iterator"].(map[string]interface{})
+	if iteratorStep == nil {
+		iteratorStep, _ = mapStep["ItemProcessor"].(map[string]interface{})
+	}
+	iteratorPath := append(path[:len(path):len(path)], "Iterator")
+	startAt, _ := iteratorStep["StartAt"].(string)
+	states, _ := iteratorStep["States"].(map[string]interface{})
+	if startAt == "" || states == nil {
+		c.problems.Append(invalidMapIterator(path))
+		return nil
+	}
+
+	iterator := c.nested(startAt, states, c.graph.nestedLocation(path[len(path)-1], "Iterator"))
+	selector := "ItemSelector"
+	if _, ok := mapStep[selector]; !ok {
+		selector = "Parameters"
+	}
+	var outputs []*stateValue
+	for _, item := range items {
+		context := newObjectValue(map[string]*stateValue{
+			"Map": newObjectValue(map[string]*stateValue{
+				"Item": newObjectValue(map[string]*stateValue{
+					"Index": newProtoValue(fieldType{Name: "int32"}),
+					"Value": item,
+				}),
+			}),
+		})
+		iterationInput := item
+		if _, ok := mapStep[selector]; ok {
+			iterationInput = c.applyTemplate(mapStep, selector, input, context, path)
+		}
+		for _, output := range iterator.walk(iterationInput, iteratorPath) {
+			outputs = append(outputs, newListValue(output).withOrigin(output.origin))
+		}
+	}
+	return outputs
+}
+
+// resolveItems возвращает возможные значения элементов массива по ItemsPath
+func (c *flowChecker) resolveItems(itemsPath string, input *stateValue, path []string) []*stateValue {
+	items := c.expand(c.readPath(input, nil, itemsPath, "ItemsPath", path), path)
+	switch items.kind {
+	case listKind:
+		return []*stateValue{items.elem}
+	case tupleKind:
+		return items.items
+	case anyKind:
+		return []*stateValue{items}
+	}
+	c.problems.Append(itemsPathIsNotArray(path, itemsPath, items))
+	return nil
+}
+
+// nested возвращает проверку вложенного автомата с теми же proto3 данными.
+// location - положение вложенного автомата в плейбуке.
+func (c *flowChecker) nested(startAt string, states map[string]interface{}, location []string) *flowChecker {
+	return &flowChecker{
+		graph:         newStateGraph(startAt, states, location, c.problems),
+		types:         c.types,
+		compatibility: c.compatibility,
+		problems:      c.problems,
+		usage:         c.usage,
+	}
+}
+
+// checkTask сверяет request rpc метода из Resource задачи с входом задачи -
+// значением после InputPath и Parameters - и возвращает response как результат задачи.
+// Задачи, которые вызывают не gRPC метод, например Lambda, не проверяются, их результат - любое значение.
+// Если rpc метод или response сообщение не найдены, возвращает nil.
+func (c *flowChecker) checkTask(taskStep map[string]interface{}, input *stateValue, path []string) *stateValue {
+	resourceString, _ := taskStep["Resource"].(string)
+	resource, err := parseGrpcResource(resourceString)
+	switch {
+	case errors.Is(err, errNotGrpcResource):
+		return newAnyValue()
+	case err != nil:
+		c.problems.Append(invalidResource(path, resourceString, err))
+		return nil
+	}
+
+	input = c.expand(input, path)
+	checkRequest := input.kind == objectKind
+	if !checkRequest && input.kind != anyKind {
+		c.problems.Append(inputIsNotObject(path, input))
+	}
+	rpc, ok := c.findRPC(resource, path)
+	if !ok {
+		return nil
+	}
+	//Обработка запроса
+	rpcRequestMessageType, requestFields, ok := c.messageFields(rpc.Request, rpc.Scope, path)
+	//Проверка на соотвестивие запрашиваемых данных и данных из актуального стейта
+	if ok && checkRequest {
+		for _, group := range groupRequestFields(requestFields) {
+			field, ok := c.compatibility.findMember(input.fields, group)
+			value, _ := memberValue(input.fields, field)
+			if ok {
+				if c.usage != nil {
+					c.usage.read(value)
+				}
+				continue
+			}
+			switch {
+			case field.Name != "":
+				c.problems.Append(invalidFieldType(path, field.Name, value,
+					field.Type.String(), rpcRequestMessageType))
+			case len(group) == 1:
+				c.problems.Append(fieldDoesNotExist(path, group[0].Name, rpcRequestMessageType, input))
+			default:
+				c.problems.Append(oneofDoesNotExist(path, group[0].Oneof, oneofMemberNames(group),
+					rpcRequestMessageType, input))
+			}
+		}
+	}
+	//Обработка ответа -> результат задачи, который дальше обрабатывают ResultSelector и ResultPath
+	rpcResponseMessageType, responseFields, ok := c.messageFields(rpc.Response, rpc.Scope, path)
+	if !ok {
+		return nil
+	}
+	origin := stateOrigin(OriginResponse, path)
+	origin.Message = rpcResponseMessageType
+	if c.usage != nil {
+		c.usage.produce(origin, responseFields)
+	}
+	return newProtoValue(fieldType{Name: rpcResponseMessageType}).withOrigin(origin)
+}
+
+// findRPC ищет rpc метод по package, сервису и методу из Resource задачи
+func (c *flowChecker) findRPC(resource *grpcResource, path []string) (*protoRPC, bool) {
+	rpc, err := c.types.resolveRPC(resource.Package, resource.Service, resource.Method)
+	var ambiguous *ambiguousSymbolError
+	switch {
+	case err == nil:
+		return rpc, true
+	case errors.As(err, &ambiguous):
+		c.problems.Append(ambiguousReference(path, ambiguous.name, ambiguous.candidates))
+	case errors.Is(err, errMethodNotFound):
+		c.problems.Append(rpcMethodDoesNotExist(path, resource.FullService(), resource.Method))
+	default:
+		c.problems.Append(serviceDoesNotExist(path, resource.FullService()))
+	}
+	return nil, false
+}
+
+// messageFields ищет сообщение messageType из области видимости scope.
+// Возвращает полное имя сообщения и его поля. Типы сообщений и enum разрешены до полных имён.
+func (c *flowChecker) messageFields(messageType string, scope string, path []string) (string, []protoField, bool) {
+	fullName, isEnum, err := c.types.resolveType(messageType, scope)
+	if err == nil && isEnum {
+		err = errSymbolNotFound
+	}
+	if err == nil {
+		var fields []protoField
+		fields, err = c.types.messageFields(fullName)
+		if err == nil {
+			return fullName, fields, true
+		}
+	}
+	c.reportLookupError(path, messageType, err)
+	return "", nil, false
+}
+
+// reportLookupError добавляет ошибку поиска сообщения или enum name
+func (c *flowChecker) reportLookupError(path []string, name string, err error) {
+	var ambiguous *ambiguousSymbolError
+	if errors.As(err, &ambiguous) {
+		c.problems.Append(ambiguousReference(path, ambiguous.name, ambiguous.candidates))
+		return
+	}
+	c.problems.Append(messageBodyDoesNotExist(path, name))
+}
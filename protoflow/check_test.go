@@ -0,0 +1,742 @@
+package protoflow
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const resourcePrefix = "grpc:127.0.0.1:5678/example.incident.Incidents/"
+
+func loadTestRegistry(t *testing.T) Registry {
+	t.Helper()
+
+	registry, err := LoadProtoFiles("testdata/incident.proto", nil, ParseOptions{Permissive: true})
+	require.NoError(t, err)
+	return registry
+}
+
+func TestCheck(t *testing.T) {
+	t.Parallel()
+
+	registry := loadTestRegistry(t)
+	testCases := []struct {
+		name       string
+		definition string
+		options    Options
+		expected   []Issue
+	}{
+		{
+			name: "valid flow",
+			definition: `{
+				"Comment": "@input_type example.incident.GetIncident",
+				"StartAt": "Get",
+				"States": {
+					"Get": {"Type": "Task", "Resource": "` + resourcePrefix + `Get", "Next": "Block"},
+					"Block": {"Type": "Task", "Resource": "` + resourcePrefix + `Block", "End": true}
+				}
+			}`,
+		},
+		{
+			name: "undeclared input is not checked against the first task",
+			definition: `{
+				"StartAt": "Block",
+				"States": {
+					"Block": {"Type": "Task", "Resource": "` + resourcePrefix + `Block", "End": true}
+				}
+			}`,
+		},
+		{
+			name: "declared input is checked against the first task",
+			definition: `{
+				"StartAt": "Block",
+				"States": {
+					"Block": {"Type": "Task", "Resource": "` + resourcePrefix + `Block", "End": true}
+				}
+			}`,
+			options: Options{InputType: "example.incident.GeoIP"},
+			expected: []Issue{
+				{Code: FieldMissing, State: "Block", Path: []string{"Block"},
+					MessageType: "example.incident.BlockUser", Field: "user_name",
+					Origin: Origin{Kind: OriginInput, Message: "example.incident.GeoIP"}},
+				{Code: FieldMissing, State: "Block", Path: []string{"Block"},
+					MessageType: "example.incident.BlockUser", Field: "severity",
+					Origin: Origin{Kind: OriginInput, Message: "example.incident.GeoIP"}},
+			},
+		},
+		{
+			name: "input type from the playbook annotation",
+			definition: `{
+				"Comment": "Blocks the user. @input_type example.incident.BlockResult",
+				"StartAt": "Block",
+				"States": {
+					"Block": {"Type": "Task", "Resource": "` + resourcePrefix + `Block",
+						"Parameters": {"user_name": "admin", "severity.$": "$.blocked"}, "End": true}
+				}
+			}`,
+			expected: []Issue{
+				{Code: FieldTypeMismatch, State: "Block", Path: []string{"Block"},
+					MessageType: "example.incident.BlockUser", Field: "severity", Expected: "int64", Actual: "bool",
+					Origin: Origin{Kind: OriginInput, Message: "example.incident.BlockResult", Field: "blocked"}},
+			},
+		},
+		{
+			name: "input type option overrides the annotation",
+			definition: `{
+				"Comment": "@input_type example.incident.GeoIP",
+				"StartAt": "Block",
+				"States": {
+					"Block": {"Type": "Task", "Resource": "` + resourcePrefix + `Block", "End": true}
+				}
+			}`,
+			options: Options{InputType: "example.incident.BlockUser"},
+		},
+		{
+			name: "unknown input type",
+			definition: `{
+				"StartAt": "Block",
+				"States": {
+					"Block": {"Type": "Task", "Resource": "` + resourcePrefix + `Block", "End": true}
+				}
+			}`,
+			options: Options{InputType: "example.incident.Missing"},
+			expected: []Issue{
+				{Code: MessageNotFound, MessageType: "example.incident.Missing"},
+			},
+		},
+		{
+			name: "End false is not a terminal state",
+			definition: `{
+				"StartAt": "Start",
+				"States": {
+					"Start": {"Type": "Pass", "End": false, "Next": "Block"},
+					"Block": {"Type": "Task", "Resource": "` + resourcePrefix + `Block", "End": true}
+				}
+			}`,
+			options: Options{InputType: "example.incident.GeoIP"},
+			expected: []Issue{
+				{Code: FieldMissing, State: "Block", Path: []string{"Start", "Block"},
+					MessageType: "example.incident.BlockUser", Field: "user_name",
+					Origin: Origin{Kind: OriginInput, Message: "example.incident.GeoIP"}},
+				{Code: FieldMissing, State: "Block", Path: []string{"Start", "Block"},
+					MessageType: "example.incident.BlockUser", Field: "severity",
+					Origin: Origin{Kind: OriginInput, Message: "example.incident.GeoIP"}},
+			},
+		},
+		{
+			name: "choice branches keep their own inputs",
+			definition: `{
+				"StartAt": "Choose",
+				"States": {
+					"Choose": {"Type": "Choice",
+						"Choices": [{"Variable": "$.country", "StringEquals": "RU", "Next": "Known"}], "Default": "Block"},
+					"Known": {"Type": "Pass", "Result": {"user_name": "admin", "severity": 3}, "Next": "Block"},
+					"Block": {"Type": "Task", "Resource": "` + resourcePrefix + `Block", "End": true}
+				}
+			}`,
+			options: Options{InputType: "example.incident.GeoIP"},
+			expected: []Issue{
+				{Code: FieldMissing, State: "Block", Path: []string{"Choose", "Block"},
+					MessageType: "example.incident.BlockUser", Field: "user_name",
+					Origin: Origin{Kind: OriginInput, Message: "example.incident.GeoIP"}},
+				{Code: FieldMissing, State: "Block", Path: []string{"Choose", "Block"},
+					MessageType: "example.incident.BlockUser", Field: "severity",
+					Origin: Origin{Kind: OriginInput, Message: "example.incident.GeoIP"}},
+			},
+		},
+		{
+			name: "parallel output is an array of branch outputs",
+			definition: `{
+				"StartAt": "Fork",
+				"States": {
+					"Fork": {
+						"Type": "Parallel",
+						"Branches": [
+							{"StartAt": "Get", "States": {
+								"Get": {"Type": "Task", "Resource": "` + resourcePrefix + `Get", "End": true}}},
+							{"StartAt": "Geo", "States": {
+								"Geo": {"Type": "Task", "Resource": "` + resourcePrefix + `Geo",
+									"Parameters": {"ip": "10.0.0.1"}, "End": true}}}
+						],
+						"Next": "Block"
+					},
+					"Block": {
+						"Type": "Task",
+						"Resource": "` + resourcePrefix + `Block",
+						"Parameters": {"user_name.$": "$[0].user_name", "severity.$": "$[1].country"},
+						"End": true
+					}
+				}
+			}`,
+			options: Options{InputType: "GetIncident"},
+			expected: []Issue{
+				{Code: FieldTypeMismatch, State: "Block", Path: []string{"Fork", "Block"},
+					MessageType: "example.incident.BlockUser", Field: "severity", Expected: "int64", Actual: "string",
+					Origin: Origin{Kind: OriginResponse, State: "Geo", Step: 3, Message: "example.incident.GeoIP",
+						Field: "country"}},
+			},
+		},
+		{
+			name: "strict compatibility rejects numeric widening",
+			definition: `{
+				"StartAt": "Get",
+				"States": {
+					"Get": {"Type": "Task", "Resource": "` + resourcePrefix + `Get", "Next": "Block"},
+					"Block": {"Type": "Task", "Resource": "` + resourcePrefix + `Block", "End": true}
+				}
+			}`,
+			options: Options{InputType: "GetIncident", Compatibility: Strict},
+			expected: []Issue{
+				{Code: FieldTypeMismatch, State: "Block", Path: []string{"Get", "Block"},
+					MessageType: "example.incident.BlockUser", Field: "severity", Expected: "int64", Actual: "int32",
+					Origin: Origin{Kind: OriginResponse, State: "Get", Step: 1, Message: "example.incident.Incident",
+						Field: "severity"}},
+			},
+		},
+		{
+			name: "parameters and map items",
+			definition: `{
+				"StartAt": "Get",
+				"States": {
+					"Get": {"Type": "Task", "Resource": "` + resourcePrefix + `Get", "ResultPath": "$.incident",
+						"Next": "Enrich"},
+					"Enrich": {
+						"Type": "Map",
+						"ItemsPath": "$.incident.ip",
+						"ItemSelector": {"ip.$": "$$.Map.Item.Value"},
+						"Iterator": {
+							"StartAt": "Geo",
+							"States": {
+								"Geo": {"Type": "Task", "Resource": "` + resourcePrefix + `Geo", "End": true}
+							}
+						},
+						"ResultPath": "$.geo",
+						"Next": "Notify"
+					},
+					"Notify": {
+						"Type": "Task",
+						"Resource": "` + resourcePrefix + `Notify",
+						"Parameters": {"chat.$": "$.geo[0].country"},
+						"End": true
+					}
+				}
+			}`,
+			options: Options{InputType: "GetIncident"},
+		},
+		{
+			name: "map result is repeated iterator output",
+			definition: `{
+				"StartAt": "Get",
+				"States": {
+					"Get": {"Type": "Task", "Resource": "` + resourcePrefix + `Get", "Next": "Enrich"},
+					"Enrich": {
+						"Type": "Map",
+						"ItemsPath": "$.ip",
+						"Iterator": {
+							"StartAt": "Geo",
+							"States": {
+								"Geo": {"Type": "Task", "Resource": "` + resourcePrefix + `Geo",
+									"Parameters": {"ip.$": "$"}, "End": true}
+							}
+						},
+						"ResultPath": "$.geo",
+						"Next": "Block"
+					},
+					"Block": {
+						"Type": "Task",
+						"Resource": "` + resourcePrefix + `Block",
+						"Parameters": {"user_name.$": "$.geo", "severity.$": "$.severity"},
+						"End": true
+					}
+				}
+			}`,
+			options: Options{InputType: "GetIncident"},
+			expected: []Issue{
+				{Code: FieldTypeMismatch, State: "Block", Path: []string{"Get", "Enrich", "Block"},
+					MessageType: "example.incident.BlockUser", Field: "user_name", Expected: "string",
+					Actual: "repeated example.incident.GeoIP",
+					Origin: Origin{Kind: OriginResponse, State: "Geo", Step: 4, Message: "example.incident.GeoIP"}},
+			},
+		},
+		{
+			name: "map items path is not a repeated field",
+			definition: `{
+				"StartAt": "Get",
+				"States": {
+					"Get": {"Type": "Task", "Resource": "` + resourcePrefix + `Get", "Next": "Enrich"},
+					"Enrich": {
+						"Type": "Map",
+						"ItemsPath": "$.user_name",
+						"Iterator": {
+							"StartAt": "Done",
+							"States": {"Done": {"Type": "Succeed"}}
+						},
+						"End": true
+					}
+				}
+			}`,
+			options: Options{InputType: "GetIncident"},
+			expected: []Issue{
+				{Code: ItemsNotArray, State: "Enrich", Path: []string{"Get", "Enrich"},
+					Attribute: "ItemsPath", JSONPath: "$.user_name", Actual: "string",
+					Origin: Origin{Kind: OriginResponse, State: "Get", Step: 1, Message: "example.incident.Incident",
+						Field: "user_name"}},
+			},
+		},
+		{
+			name: "result selector, result path and output path shape the next input",
+			definition: `{
+				"StartAt": "Get",
+				"States": {
+					"Get": {"Type": "Task", "Resource": "` + resourcePrefix + `Get",
+						"ResultSelector": {"user_name.$": "$.user_name", "level.$": "$.severity"},
+						"ResultPath": "$.found", "OutputPath": "$.found", "Next": "Block"},
+					"Block": {"Type": "Task", "Resource": "` + resourcePrefix + `Block", "End": true}
+				}
+			}`,
+			options: Options{InputType: "GetIncident"},
+			expected: []Issue{
+				{Code: FieldMissing, State: "Block", Path: []string{"Get", "Block"},
+					MessageType: "example.incident.BlockUser", Field: "severity",
+					Origin: Origin{Kind: OriginPlaybook, State: "Get", Step: 1, Attribute: "ResultSelector"}},
+			},
+		},
+		{
+			name: "input path selects and null result path discards",
+			definition: `{
+				"StartAt": "Get",
+				"States": {
+					"Get": {"Type": "Task", "Resource": "` + resourcePrefix + `Get", "Next": "Geo"},
+					"Geo": {"Type": "Task", "Resource": "` + resourcePrefix + `Geo",
+						"InputPath": "$.ip", "ResultPath": null, "Next": "Block"},
+					"Block": {"Type": "Task", "Resource": "` + resourcePrefix + `Block", "End": true}
+				}
+			}`,
+			options: Options{InputType: "GetIncident"},
+			expected: []Issue{
+				{Code: InputNotObject, State: "Geo", Path: []string{"Get", "Geo"}, Actual: "repeated string",
+					Origin: Origin{Kind: OriginResponse, State: "Get", Step: 1, Message: "example.incident.Incident",
+						Field: "ip"}},
+			},
+		},
+		{
+			name: "oneof and missing path",
+			definition: `{
+				"StartAt": "Get",
+				"States": {
+					"Get": {"Type": "Task", "Resource": "` + resourcePrefix + `Get", "Next": "Notify"},
+					"Notify": {
+						"Type": "Task",
+						"Resource": "` + resourcePrefix + `Notify",
+						"Parameters": {"room.$": "$.room"},
+						"End": true
+					}
+				}
+			}`,
+			options: Options{InputType: "GetIncident"},
+			expected: []Issue{
+				{Code: PathNotFound, State: "Notify", Path: []string{"Get", "Notify"},
+					Attribute: "Parameters", JSONPath: "$.room", Field: "$.room", Actual: "example.incident.Incident",
+					Origin: Origin{Kind: OriginResponse, State: "Get", Step: 1, Message: "example.incident.Incident"}},
+				{Code: OneofMissing, State: "Notify", Path: []string{"Get", "Notify"},
+					MessageType: "example.incident.Notify", Field: "target", Candidates: []string{"user_name", "chat"},
+					Origin: Origin{Kind: OriginPlaybook, State: "Notify", Step: 2, Attribute: "Parameters"}},
+			},
+		},
+		{
+			name: "literal parameter keeps its origin",
+			definition: `{
+				"StartAt": "Get",
+				"States": {
+					"Get": {"Type": "Task", "Resource": "` + resourcePrefix + `Get", "Next": "Block"},
+					"Block": {
+						"Type": "Task",
+						"Resource": "` + resourcePrefix + `Block",
+						"Parameters": {"user_name": true, "severity.$": "$.severity"},
+						"End": true
+					}
+				}
+			}`,
+			options: Options{InputType: "GetIncident"},
+			expected: []Issue{
+				{Code: FieldTypeMismatch, State: "Block", Path: []string{"Get", "Block"},
+					MessageType: "example.incident.BlockUser", Field: "user_name", Expected: "string", Actual: "true",
+					Origin: Origin{Kind: OriginPlaybook, State: "Block", Step: 2, Attribute: "Parameters",
+						Field: "user_name"}},
+			},
+		},
+		{
+			name: "unknown rpc and message",
+			definition: `{
+				"StartAt": "Unknown",
+				"States": {
+					"Unknown": {"Type": "Task", "Resource": "` + resourcePrefix + `Unknown", "Next": "Broken"},
+					"Broken": {"Type": "Task", "Resource": "` + resourcePrefix + `Broken", "End": true}
+				}
+			}`,
+			expected: []Issue{
+				{Code: RPCNotFound, State: "Unknown", Path: []string{"Unknown"},
+					MessageType: "example.incident.Incidents", Field: "Unknown"},
+			},
+		},
+		{
+			name: "problems of every state are reported",
+			definition: `{
+				"StartAt": "Block",
+				"States": {
+					"Block": {"Type": "Task", "Resource": "` + resourcePrefix + `Block", "Next": "Notify"},
+					"Notify": {"Type": "Task", "Resource": "` + resourcePrefix + `Notify",
+						"Parameters": {"chat": 1}, "Next": "Unknown"},
+					"Unknown": {"Type": "Task", "Resource": "` + resourcePrefix + `Unknown", "End": true}
+				}
+			}`,
+			options: Options{InputType: "example.incident.GeoIP"},
+			expected: []Issue{
+				{Code: FieldMissing, State: "Block", Path: []string{"Block"},
+					MessageType: "example.incident.BlockUser", Field: "user_name",
+					Origin: Origin{Kind: OriginInput, Message: "example.incident.GeoIP"}},
+				{Code: FieldMissing, State: "Block", Path: []string{"Block"},
+					MessageType: "example.incident.BlockUser", Field: "severity",
+					Origin: Origin{Kind: OriginInput, Message: "example.incident.GeoIP"}},
+				{Code: FieldTypeMismatch, State: "Notify", Path: []string{"Block", "Notify"},
+					MessageType: "example.incident.Notify", Field: "chat", Expected: "string", Actual: "1",
+					Origin: Origin{Kind: OriginPlaybook, State: "Notify", Step: 2, Attribute: "Parameters",
+						Field: "chat"}},
+				{Code: RPCNotFound, State: "Unknown", Path: []string{"Block", "Notify", "Unknown"},
+					MessageType: "example.incident.Incidents", Field: "Unknown"},
+			},
+		},
+		{
+			name: "unknown service",
+			definition: `{
+				"StartAt": "Get",
+				"States": {
+					"Get": {"Type": "Task", "Resource": "grpc:127.0.0.1:5678/example.incident.Alerts/Get", "End": true}
+				}
+			}`,
+			expected: []Issue{
+				{Code: ServiceNotFound, State: "Get", Path: []string{"Get"}, MessageType: "example.incident.Alerts"},
+			},
+		},
+		{
+			name: "missing request message",
+			definition: `{
+				"StartAt": "Broken",
+				"States": {
+					"Broken": {"Type": "Task", "Resource": "` + resourcePrefix + `Broken", "End": true}
+				}
+			}`,
+			expected: []Issue{
+				{Code: MessageNotFound, State: "Broken", Path: []string{"Broken"}, MessageType: "Missing"},
+			},
+		},
+		{
+			name: "polling loop terminates",
+			definition: `{
+				"StartAt": "Get",
+				"States": {
+					"Get": {"Type": "Task", "Resource": "` + resourcePrefix + `Get", "Next": "Wait"},
+					"Wait": {"Type": "Wait", "Seconds": 5, "Next": "Block"},
+					"Block": {"Type": "Task", "Resource": "` + resourcePrefix + `Block",
+						"ResultPath": "$.result", "Next": "Done"},
+					"Done": {"Type": "Choice",
+						"Choices": [{"Variable": "$.result.blocked", "BooleanEquals": true, "Next": "End"}],
+						"Default": "Wait"},
+					"End": {"Type": "Succeed"}
+				}
+			}`,
+			options: Options{InputType: "GetIncident"},
+		},
+		{
+			name: "loop input reaches a fixpoint",
+			definition: `{
+				"StartAt": "Get",
+				"States": {
+					"Get": {"Type": "Task", "Resource": "` + resourcePrefix + `Get", "Next": "Block"},
+					"Block": {"Type": "Task", "Resource": "` + resourcePrefix + `Block", "Next": "Retry"},
+					"Retry": {"Type": "Choice",
+						"Choices": [{"Variable": "$.blocked", "BooleanEquals": true, "Next": "Done"}],
+						"Default": "Block"},
+					"Done": {"Type": "Succeed"}
+				}
+			}`,
+			options: Options{InputType: "GetIncident"},
+			expected: []Issue{
+				{Code: FieldMissing, State: "Block", Path: []string{"Get", "Block", "Retry", "Block"},
+					MessageType: "example.incident.BlockUser", Field: "user_name",
+					Origin: Origin{Kind: OriginResponse, State: "Block", Step: 2, Message: "example.incident.BlockResult"}},
+				{Code: FieldMissing, State: "Block", Path: []string{"Get", "Block", "Retry", "Block"},
+					MessageType: "example.incident.BlockUser", Field: "severity",
+					Origin: Origin{Kind: OriginResponse, State: "Block", Step: 2, Message: "example.incident.BlockResult"}},
+			},
+		},
+		{
+			name: "catch handler gets the error object",
+			definition: `{
+				"StartAt": "Get",
+				"States": {
+					"Get": {"Type": "Task", "Resource": "` + resourcePrefix + `Get",
+						"Catch": [{"ErrorEquals": ["States.ALL"], "ResultPath": "$.error", "Next": "Block"}],
+						"Next": "Block"},
+					"Block": {"Type": "Task", "Resource": "` + resourcePrefix + `Block", "End": true}
+				}
+			}`,
+			options: Options{InputType: "GetIncident"},
+			expected: []Issue{
+				{Code: FieldMissing, State: "Block", Path: []string{"Get", "Catch[0]", "Block"},
+					MessageType: "example.incident.BlockUser", Field: "user_name",
+					Origin: Origin{Kind: OriginInput, Message: "example.incident.GetIncident"}},
+				{Code: FieldMissing, State: "Block", Path: []string{"Get", "Catch[0]", "Block"},
+					MessageType: "example.incident.BlockUser", Field: "severity",
+					Origin: Origin{Kind: OriginInput, Message: "example.incident.GetIncident"}},
+			},
+		},
+		{
+			name: "catch error object replaces or merges into the input",
+			definition: `{
+				"StartAt": "Get",
+				"States": {
+					"Get": {"Type": "Task", "Resource": "` + resourcePrefix + `Get",
+						"Catch": [
+							{"ErrorEquals": ["States.Timeout"], "ResultPath": "$.error", "Next": "Notify"},
+							{"ErrorEquals": ["States.ALL"], "Next": "Block"}
+						],
+						"Next": "Done"},
+					"Notify": {"Type": "Task", "Resource": "` + resourcePrefix + `Notify",
+						"Parameters": {"chat.$": "$.error.Cause"}, "Next": "Done"},
+					"Block": {"Type": "Task", "Resource": "` + resourcePrefix + `Block",
+						"Parameters": {"user_name.$": "$.Error", "severity.$": "$.id"}, "Next": "Done"},
+					"Done": {"Type": "Succeed"}
+				}
+			}`,
+			options: Options{InputType: "GetIncident"},
+			expected: []Issue{
+				{Code: PathNotFound, State: "Block", Path: []string{"Get", "Catch[1]", "Block"},
+					Attribute: "Parameters", JSONPath: "$.id", Field: "$.id", Actual: "{Cause string, Error string}",
+					Origin: Origin{Kind: OriginError, State: "Get", Step: 1, Attribute: "Catch[1]"}},
+			},
+		},
+		{
+			name: "tasks that call AWS services are not checked",
+			definition: `{
+				"StartAt": "Enrich",
+				"States": {
+					"Enrich": {"Type": "Task", "Resource": "arn:aws:lambda:eu-west-1:123456789012:function:enrich",
+						"Next": "Block"},
+					"Block": {"Type": "Task", "Resource": "` + resourcePrefix + `Block", "End": true}
+				}
+			}`,
+			options: Options{InputType: "example.incident.GeoIP"},
+		},
+		{
+			name: "malformed grpc resource",
+			definition: `{
+				"StartAt": "Block",
+				"States": {
+					"Block": {"Type": "Task", "Resource": "grpc:127.0.0.1/example.incident.Incidents/Block", "End": true}
+				}
+			}`,
+			expected: []Issue{
+				{Code: ResourceInvalid, State: "Block", Path: []string{"Block"},
+					Actual: "grpc:127.0.0.1/example.incident.Incidents/Block",
+					Reason: "invalid address \"127.0.0.1\": address 127.0.0.1: missing port in address"},
+			},
+		},
+		{
+			name: "problem on several paths is reported once",
+			definition: `{
+				"StartAt": "Choose",
+				"States": {
+					"Choose": {"Type": "Choice",
+						"Choices": [{"Variable": "$.ip", "StringEquals": "127.0.0.1", "Next": "Local"}], "Default": "Remote"},
+					"Local": {"Type": "Pass", "Result": "local", "ResultPath": "$.note", "Next": "Block"},
+					"Remote": {"Type": "Pass", "Next": "Block"},
+					"Block": {"Type": "Task", "Resource": "` + resourcePrefix + `Block", "End": true}
+				}
+			}`,
+			options: Options{InputType: "example.incident.GeoIP"},
+			expected: []Issue{
+				{Code: FieldMissing, State: "Block", Path: []string{"Choose", "Local", "Block"},
+					MessageType: "example.incident.BlockUser", Field: "user_name",
+					Origin: Origin{Kind: OriginInput, Message: "example.incident.GeoIP"}},
+				{Code: FieldMissing, State: "Block", Path: []string{"Choose", "Local", "Block"},
+					MessageType: "example.incident.BlockUser", Field: "severity",
+					Origin: Origin{Kind: OriginInput, Message: "example.incident.GeoIP"}},
+			},
+		},
+		{
+			name: "missing transition target",
+			definition: `{
+				"StartAt": "Start",
+				"States": {
+					"Start": {"Type": "Pass", "Next": "Nowhere"}
+				}
+			}`,
+			expected: []Issue{
+				{Code: StateNotFound, State: "Start", Path: []string{"Start"}, Field: "Nowhere"},
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			issues, err := Check([]byte(tc.definition), registry, tc.options)
+			require.NoError(t, err)
+			if tc.expected == nil {
+				tc.expected = []Issue{}
+			}
+			assert.Equal(t, tc.expected, issues)
+		})
+	}
+}
+
+func TestCheck_FieldTypesFollowProtoScoping(t *testing.T) {
+	t.Parallel()
+
+	registry, err := LoadProtoFiles("testdata/scoping.proto", nil, ParseOptions{})
+	require.NoError(t, err)
+
+	definition := `{
+		"StartAt": "Find",
+		"States": {
+			"Find": {"Type": "Task", "Resource": "grpc:127.0.0.1:5678/example.scoping.Lookups/Find",
+				"Parameters": {"geo.$": "$.geo", "resolved.$": "$.resolved"},
+				"ResultSelector": {"geo.$": "$.geo.country", "resolved.$": "$.resolved.country"},
+				"End": true}
+		}
+	}`
+
+	issues, err := Check([]byte(definition), registry, Options{})
+	require.NoError(t, err)
+	assert.Equal(t, []Issue{{Code: MessageNotFound, State: "Find", Path: []string{"Find"}, MessageType: "GeoIP"}}, issues)
+}
+
+func TestCheck_JSONNamePaths(t *testing.T) {
+	t.Parallel()
+
+	registry, err := LoadProtoFiles("testdata/events.proto", nil, ParseOptions{Permissive: true})
+	require.NoError(t, err)
+
+	// поля response читаются и по именам из proto3 JSON: lowerCamelCase и json_name
+	definition := `{
+		"StartAt": "Get",
+		"States": {
+			"Get": {"Type": "Task", "Resource": "` + eventsPrefix + `Get",
+				"ResultSelector": {"eventId.$": "$.eventId", "createdAt.$": "$.createdAt", "source.$": "$.sourceAddress"},
+				"ResultPath": "$.event", "Next": "Record"},
+			"Record": {
+				"Type": "Task",
+				"Resource": "` + eventsPrefix + `Record",
+				"Parameters": {
+					"event_id.$": "$.event.eventId",
+					"created_at.$": "$.event.createdAt",
+					"level.$": "$.event.source",
+					"retries": 1,
+					"details": null
+				},
+				"End": true
+			}
+		}
+	}`
+
+	issues, err := Check([]byte(definition), registry, Options{InputType: "GetEvent"})
+	require.NoError(t, err)
+	assert.Empty(t, issues)
+}
+
+func TestCheck_ParallelCombinations(t *testing.T) {
+	t.Parallel()
+
+	// parallel возвращает Parallel из count веток, у каждой из которых два разных выхода
+	parallel := func(count int) string {
+		branch := `{
+			"StartAt": "Choose",
+			"States": {
+				"Choose": {"Type": "Choice", "Choices": [{"Variable": "$.x", "StringEquals": "a", "Next": "A"}], "Default": "B"},
+				"A": {"Type": "Pass", "Result": {"a": 1}, "End": true},
+				"B": {"Type": "Pass", "Result": {"b": 1}, "End": true}
+			}
+		}`
+		branches := make([]string, count)
+		for i := range branches {
+			branches[i] = branch
+		}
+		return `{
+			"StartAt": "Fork",
+			"States": {
+				"Fork": {"Type": "Parallel", "Branches": [` + strings.Join(branches, ", ") + `], "End": true}
+			}
+		}`
+	}
+
+	registry := loadTestRegistry(t)
+
+	issues, err := Check([]byte(parallel(5)), registry, Options{})
+	require.NoError(t, err)
+	assert.Empty(t, issues)
+
+	issues, err = Check([]byte(parallel(6)), registry, Options{})
+	require.NoError(t, err)
+	assert.Equal(t, []Issue{{Code: TooManyInputs, State: "Fork", Path: []string{"Fork"}}}, issues)
+}
+
+func TestCheck_InvalidDefinition(t *testing.T) {
+	t.Parallel()
+
+	_, err := Check([]byte(`["StartAt"]`), loadTestRegistry(t), Options{})
+	assert.Error(t, err)
+}
+
+func TestCheck_ServicesInSeveralPackages(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	files := map[string]string{
+		"a.proto": `syntax = "proto3";
+package example.a;
+import "b.proto";
+message Req { string name = 1; }
+service Lookups { rpc Get (Req) returns (Req) {} }
+service Alerts { rpc Raise (Req) returns (example.b.Req) {} }
+`,
+		"b.proto": `syntax = "proto3";
+package example.b;
+message Req { int32 name = 1; }
+service Lookups { rpc Get (Req) returns (Req) {} }
+`,
+	}
+	for name, content := range files {
+		require.NoError(t, os.WriteFile(filepath.Join(dir, name), []byte(content), 0o600))
+	}
+	registry, err := LoadProtoFiles(filepath.Join(dir, "a.proto"), nil, ParseOptions{})
+	require.NoError(t, err)
+
+	// сервисы и сообщения с одним именем в разных package не смешиваются,
+	// а имя сервиса без package должно быть однозначным
+	definition := `{
+		"StartAt": "Raise",
+		"States": {
+			"Raise": {"Type": "Task", "Resource": "grpc:127.0.0.1:5678/Alerts/Raise", "Next": "GetB"},
+			"GetB": {"Type": "Task", "Resource": "grpc:127.0.0.1:5678/example.b.Lookups/Get", "Next": "GetA"},
+			"GetA": {"Type": "Task", "Resource": "grpc:127.0.0.1:5678/example.a.Lookups/Get", "Next": "Get"},
+			"Get": {"Type": "Task", "Resource": "grpc:127.0.0.1:5678/Lookups/Get", "End": true}
+		}
+	}`
+
+	issues, err := Check([]byte(definition), registry, Options{InputType: "example.a.Req"})
+	require.NoError(t, err)
+	assert.Equal(t, []Issue{
+		{Code: FieldTypeMismatch, State: "GetA", Path: []string{"Raise", "GetB", "GetA"},
+			MessageType: "example.a.Req", Field: "name", Expected: "string", Actual: "int32",
+			Origin: Origin{Kind: OriginResponse, State: "GetB", Step: 2, Message: "example.b.Req", Field: "name"}},
+		{Code: AmbiguousReference, State: "Get", Path: []string{"Raise", "GetB", "GetA", "Get"},
+			MessageType: "Lookups", Candidates: []string{"example.a.Lookups", "example.b.Lookups"}},
+	}, issues)
+}
@@ -0,0 +1,397 @@
+package protoflow
+
+import (
+	"encoding/base64"
+	"fmt"
+	"math"
+	"strconv"
+)
+
+// Compatibility - насколько строго сравниваются типы полей
+type Compatibility int
+
+const (
+	// Lenient разрешает безопасное расширение чисел
+	// и структурное сравнение сообщений с разными именами
+	Lenient Compatibility = iota
+	// Strict требует совпадения типов, сообщений и enum по полному имени
+	Strict
+)
+
+var compatibilityNames = map[string]Compatibility{
+	"lenient": Lenient,
+	"strict":  Strict,
+}
+
+// ParseCompatibility возвращает режим по названию: lenient или strict
+func ParseCompatibility(name string) (Compatibility, error) {
+	mode, ok := compatibilityNames[name]
+	if !ok {
+		return 0, fmt.Errorf("unknown type compatibility mode \"%s\", expected lenient or strict", name)
+	}
+	return mode, nil
+}
+
+// fieldType - тип поля proto3
+type fieldType struct {
+	// Name - скалярный тип proto3 или полное имя сообщения или enum, для map - тип значения
+	Name string
+	// Repeated - поле объявлено как repeated
+	Repeated bool
+	// MapKey - тип ключа map<k, v>, у остальных полей пустой
+	MapKey string
+}
+
+// String возвращает тип так, как он записывается в proto3, например "repeated string"
+func (t fieldType) String() string {
+	switch {
+	case t.MapKey != "":
+		return "map<" + t.MapKey + ", " + t.Name + ">"
+	case t.Repeated:
+		return repeatedPrefix + t.Name
+	}
+	return t.Name
+}
+
+// scalarEncodings - скалярные типы, которые отличаются только кодированием
+// на проводе и одинаково выглядят в JSON. Значение - базовый тип.
+var scalarEncodings = map[string]string{
+	"sint32": "int32", "sfixed32": "int32",
+	"sint64": "int64", "sfixed64": "int64",
+	"fixed32": "uint32",
+	"fixed64": "uint64",
+}
+
+// scalarWidening - базовые типы, в которые значение можно передать без потерь.
+// 64-битные числа в proto3 JSON записываются строкой, поэтому их можно передать в string.
+var scalarWidening = map[string][]string{
+	"int32":  {"int64", "double"},
+	"uint32": {"uint64", "int64", "double"},
+	"int64":  {"string"},
+	"uint64": {"string"},
+	"float":  {"double"},
+}
+
+// typeCompatibility проверяет, можно ли передать значение одного типа в поле другого
+type typeCompatibility struct {
+	mode  Compatibility
+	types Registry
+	// assumed - пары сообщений, совместимость которых сейчас проверяется.
+	// Нужна для рекурсивных сообщений.
+	assumed map[[2]string]bool
+}
+
+func newTypeCompatibility(mode Compatibility, types Registry) *typeCompatibility {
+	return &typeCompatibility{
+		mode:    mode,
+		types:   types,
+		assumed: map[[2]string]bool{},
+	}
+}
+
+// compatible сообщает, можно ли передать значение типа actual в поле типа expected.
+// repeated и map должны совпадать, а их элементы, ключи и значения - быть совместимы.
+func (c *typeCompatibility) compatible(actual fieldType, expected fieldType) bool {
+	if actual.Repeated != expected.Repeated || (actual.MapKey == "") != (expected.MapKey == "") {
+		return false
+	}
+	if expected.MapKey != "" && !c.compatibleNames(actual.MapKey, expected.MapKey) {
+		return false
+	}
+	return c.compatibleNames(actual.Name, expected.Name)
+}
+
+func (c *typeCompatibility) compatibleNames(actual string, expected string) bool {
+	if actual == expected {
+		return true
+	}
+	if c.mode == Strict {
+		return false
+	}
+
+	// в Value подходит любое значение JSON, в Struct - любой объект
+	switch expected {
+	case wellKnownValue:
+		return true
+	case wellKnownStruct:
+		_, actualIsScalar := protoScalarTypes[actual]
+		_, actualIsEnum, err := c.types.resolveType(actual, "")
+		return !actualIsScalar && err == nil && !actualIsEnum && !hasSpecialJSON(actual)
+	}
+	// обёртки google.protobuf.*Value записываются в JSON своими скалярами
+	actual, expected = jsonScalar(actual), jsonScalar(expected)
+	_, actualIsScalar := protoScalarTypes[actual]
+	_, expectedIsScalar := protoScalarTypes[expected]
+	if actualIsScalar && expectedIsScalar {
+		return compatibleScalars(actual, expected)
+	}
+	if actualIsScalar || expectedIsScalar {
+		// enum записывается в JSON именем значения, поэтому его можно передать в string
+		_, actualIsEnum, err := c.types.resolveType(actual, "")
+		return expected == "string" && err == nil && actualIsEnum
+	}
+
+	// enum совместимы только сами с собой
+	_, actualIsEnum, err := c.types.resolveType(actual, "")
+	if err != nil || actualIsEnum {
+		return false
+	}
+	_, expectedIsEnum, err := c.types.resolveType(expected, "")
+	if err != nil || expectedIsEnum {
+		return false
+	}
+	return c.compatibleMessages(actual, expected)
+}
+
+// compatibleMessages сравнивает сообщения по структуре: у actual должны быть
+// все поля expected совместимых типов, а из каждого oneof expected - хотя бы одно.
+func (c *typeCompatibility) compatibleMessages(actual string, expected string) bool {
+	key := [2]string{actual, expected}
+	if _, ok := c.assumed[key]; ok {
+		return true
+	}
+	c.assumed[key] = true
+	defer delete(c.assumed, key)
+
+	actualFields, err := c.types.messageFields(actual)
+	if err != nil {
+		return false
+	}
+	expectedFields, err := c.types.messageFields(expected)
+	if err != nil {
+		return false
+	}
+
+	available := make(map[string]*stateValue, len(actualFields))
+	for _, field := range actualFields {
+		available[field.Name] = newProtoValue(field.Type)
+	}
+	for _, group := range groupRequestFields(expectedFields) {
+		if _, ok := c.findMember(available, group); !ok {
+			return false
+		}
+	}
+	return true
+}
+
+// compatibleValue сообщает, можно ли передать значение value в поле типа expected.
+// Значения без типа proto3, например собранные Parameters, сравниваются
+// с сообщением по структуре в любом режиме.
+func (c *typeCompatibility) compatibleValue(value *stateValue, expected fieldType) bool {
+	if actual, ok := value.protoType(); ok {
+		return c.compatible(actual, expected)
+	}
+
+	elem := fieldType{Name: expected.Name}
+	single := !expected.Repeated && expected.MapKey == ""
+	switch {
+	case value.kind == anyKind || (single && expected.Name == wellKnownValue):
+		return true
+	case single && (expected.Name == wellKnownStruct || expected.Name == wellKnownAny):
+		return value.kind == objectKind
+	case single && expected.Name == wellKnownListValue:
+		return value.kind == tupleKind || value.kind == listKind
+	}
+	switch value.kind {
+	case literalKind:
+		return value.literal == nil || (single && c.compatibleLiteral(value.literal, expected.Name))
+	case tupleKind:
+		if !expected.Repeated {
+			return false
+		}
+		for _, item := range value.items {
+			if !c.compatibleValue(item, elem) {
+				return false
+			}
+		}
+		return true
+	case listKind:
+		return expected.Repeated && c.compatibleValue(value.elem, elem)
+	}
+
+	if expected.Repeated || hasSpecialJSON(expected.Name) {
+		return false
+	}
+	// Объект передаётся в map<string, V>, если все его поля совместимы с V
+	if expected.MapKey != "" {
+		for _, field := range value.fields {
+			if !c.compatibleValue(field, elem) {
+				return false
+			}
+		}
+		return true
+	}
+	fullName, isEnum, err := c.types.resolveType(expected.Name, "")
+	if err != nil || isEnum {
+		return false
+	}
+	expectedFields, err := c.types.messageFields(fullName)
+	if err != nil {
+		return false
+	}
+	for _, group := range groupRequestFields(expectedFields) {
+		if _, ok := c.findMember(value.fields, group); !ok {
+			return false
+		}
+	}
+	return true
+}
+
+// compatibleLiteral сообщает, можно ли передать литерал из плейбука в поле скалярного типа,
+// enum или well-known type так, как его разберёт proto3 JSON: целые числа можно записать
+// строкой, bytes - base64 строкой, enum - именем значения или номером, Timestamp - в RFC 3339.
+func (c *typeCompatibility) compatibleLiteral(literal interface{}, expected string) bool {
+	if hasSpecialJSON(expected) && jsonScalar(expected) == expected {
+		return compatibleWellKnownLiteral(literal, expected)
+	}
+	expected = jsonScalar(expected)
+	if base, ok := scalarEncodings[expected]; ok {
+		expected = base
+	}
+
+	switch literal := literal.(type) {
+	case string:
+		switch expected {
+		case "string":
+			return true
+		case "bytes":
+			return isBase64(literal)
+		case "int32", "int64", "uint32", "uint64":
+			return integerFits(literal, expected)
+		case "float", "double":
+			_, err := strconv.ParseFloat(literal, 64)
+			return err == nil || literal == "NaN" || literal == "Infinity" || literal == "-Infinity"
+		}
+		values, err := c.types.enumValues(expected)
+		if err != nil {
+			return false
+		}
+		for _, value := range values {
+			if value == literal {
+				return true
+			}
+		}
+	case bool:
+		return expected == "bool"
+	case float64:
+		switch expected {
+		case "float", "double":
+			return true
+		case "int32", "int64", "uint32", "uint64":
+			return literal == math.Trunc(literal) && integerFits(strconv.FormatFloat(literal, 'f', -1, 64), expected)
+		}
+		_, isEnum, err := c.types.resolveType(expected, "")
+		return err == nil && isEnum && literal == math.Trunc(literal)
+	}
+	return false
+}
+
+// integerFits сообщает, что десятичная запись text - целое число, которое помещается в тип expected
+func integerFits(text string, expected string) bool {
+	var err error
+	switch expected {
+	case "int32":
+		_, err = strconv.ParseInt(text, 10, 32)
+	case "int64":
+		_, err = strconv.ParseInt(text, 10, 64)
+	case "uint32":
+		_, err = strconv.ParseUint(text, 10, 32)
+	default:
+		_, err = strconv.ParseUint(text, 10, 64)
+	}
+	return err == nil
+}
+
+// isBase64 сообщает, что text - base64 в стандартном или URL алфавите, с дополнением или без
+func isBase64(text string) bool {
+	for _, encoding := range []*base64.Encoding{
+		base64.StdEncoding, base64.URLEncoding, base64.RawStdEncoding, base64.RawURLEncoding,
+	} {
+		if _, err := encoding.DecodeString(text); err == nil {
+			return true
+		}
+	}
+	return false
+}
+
+// findMember ищет в available поле из группы group совместимого типа.
+// Поле может быть записано своим именем или именем в JSON.
+// Возвращает первое найденное поле группы, даже если его тип не совместим.
+func (c *typeCompatibility) findMember(available map[string]*stateValue, group []protoField) (protoField, bool) {
+	var found *protoField
+	for i, field := range group {
+		actual, ok := memberValue(available, field)
+		if !ok {
+			continue
+		}
+		if c.compatibleValue(actual, field.Type) {
+			return field, true
+		}
+		if found == nil {
+			found = &group[i]
+		}
+	}
+	if found != nil {
+		return *found, false
+	}
+	return protoField{}, false
+}
+
+// memberValue возвращает значение поля field из available по имени поля или по его имени в JSON
+func memberValue(available map[string]*stateValue, field protoField) (*stateValue, bool) {
+	if value, ok := available[field.Name]; ok {
+		return value, true
+	}
+	if field.JSONName == "" {
+		return nil, false
+	}
+	value, ok := available[field.JSONName]
+	return value, ok
+}
+
+func compatibleScalars(actual string, expected string) bool {
+	if base, ok := scalarEncodings[actual]; ok {
+		actual = base
+	}
+	if base, ok := scalarEncodings[expected]; ok {
+		expected = base
+	}
+	if actual == expected {
+		return true
+	}
+	for _, wider := range scalarWidening[actual] {
+		if wider == expected {
+			return true
+		}
+	}
+	return false
+}
+
+// groupRequestFields группирует поля request сообщения: обычное поле - группа из одного поля,
+// поля одного oneof - одна группа, в которой достаточно любого из полей.
+func groupRequestFields(fields []protoField) [][]protoField {
+	var groups [][]protoField
+	oneofIndex := map[string]int{}
+	for _, field := range fields {
+		if field.Oneof == "" {
+			groups = append(groups, []protoField{field})
+			continue
+		}
+		if i, ok := oneofIndex[field.Oneof]; ok {
+			groups[i] = append(groups[i], field)
+			continue
+		}
+		oneofIndex[field.Oneof] = len(groups)
+		groups = append(groups, []protoField{field})
+	}
+	return groups
+}
+
+// oneofMemberNames возвращает имена полей группы
+func oneofMemberNames(group []protoField) []string {
+	names := make([]string, 0, len(group))
+	for _, field := range group {
+		names = append(names, field.Name)
+	}
+	return names
+}
@@ -0,0 +1,116 @@
+package protoflow
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const compatibilityTestProto = `syntax = "proto3";
+
+package example.types;
+
+enum Level {
+    LEVEL_UNSPECIFIED = 0;
+}
+
+enum Priority {
+    PRIORITY_UNSPECIFIED = 0;
+}
+
+message User {
+    string name = 1;
+    int32 age = 2;
+}
+
+message Person {
+    string name = 1;
+    int32 age = 2;
+    bool admin = 3;
+}
+
+message Contact {
+    oneof target {
+        string name = 1;
+        string chat = 2;
+    }
+}
+
+message Node {
+    string name = 1;
+    Node next = 2;
+}
+
+message Chain {
+    string name = 1;
+    Chain next = 2;
+}
+`
+
+func TestTypeCompatibility(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	writeProtoFiles(t, dir, map[string]string{"types.proto": compatibilityTestProto})
+	registry, err := LoadProtoFiles(filepath.Join(dir, "types.proto"), nil, ParseOptions{})
+	require.NoError(t, err)
+
+	scalar := func(name string) fieldType { return fieldType{Name: name} }
+	repeated := func(name string) fieldType { return fieldType{Name: name, Repeated: true} }
+	mapOf := func(key string, value string) fieldType { return fieldType{Name: value, MapKey: key} }
+
+	testCases := []struct {
+		actual   fieldType
+		expected fieldType
+		lenient  bool
+		strict   bool
+	}{
+		{scalar("int32"), scalar("int32"), true, true},
+		{scalar("int32"), scalar("int64"), true, false},
+		{scalar("int32"), scalar("double"), true, false},
+		{scalar("uint32"), scalar("int64"), true, false},
+		{scalar("sint32"), scalar("int64"), true, false},
+		{scalar("int64"), scalar("string"), true, false},
+		{scalar("float"), scalar("double"), true, false},
+		{scalar("int64"), scalar("int32"), false, false},
+		{scalar("uint64"), scalar("int64"), false, false},
+		{scalar("double"), scalar("float"), false, false},
+		{scalar("string"), scalar("bytes"), false, false},
+		{scalar("example.types.Level"), scalar("example.types.Level"), true, true},
+		{scalar("example.types.Level"), scalar("string"), true, false},
+		{scalar("example.types.Level"), scalar("example.types.Priority"), false, false},
+		{scalar("example.types.Level"), scalar("int32"), false, false},
+		{scalar("example.types.Person"), scalar("example.types.User"), true, false},
+		{scalar("example.types.User"), scalar("example.types.Person"), false, false},
+		{scalar("example.types.User"), scalar("example.types.Contact"), true, false},
+		{scalar("example.types.Node"), scalar("example.types.Chain"), true, false},
+		{repeated("int32"), repeated("int64"), true, false},
+		{repeated("int32"), scalar("int32"), false, false},
+		{scalar("int32"), repeated("int32"), false, false},
+		{mapOf("string", "int32"), mapOf("string", "int64"), true, false},
+		{mapOf("int32", "string"), mapOf("int64", "string"), true, false},
+		{mapOf("string", "string"), repeated("string"), false, false},
+		{mapOf("string", "int64"), mapOf("string", "int32"), false, false},
+	}
+
+	for _, tc := range testCases {
+		description := tc.actual.String() + " -> " + tc.expected.String()
+		assert.Equal(t, tc.lenient, newTypeCompatibility(Lenient, registry).compatible(tc.actual, tc.expected),
+			"lenient: "+description)
+		assert.Equal(t, tc.strict, newTypeCompatibility(Strict, registry).compatible(tc.actual, tc.expected),
+			"strict: "+description)
+	}
+}
+
+func TestParseCompatibility(t *testing.T) {
+	t.Parallel()
+
+	mode, err := ParseCompatibility("strict")
+	require.NoError(t, err)
+	assert.Equal(t, Strict, mode)
+
+	_, err = ParseCompatibility("loose")
+	assert.EqualError(t, err, `unknown type compatibility mode "loose", expected lenient or strict`)
+}
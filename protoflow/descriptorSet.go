@@ -0,0 +1,240 @@
+package protoflow
+
+import (
+	"fmt"
+	"os"
+	"sort"
+
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protodesc"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/reflect/protoregistry"
+	"google.golang.org/protobuf/types/descriptorpb"
+)
+
+// descriptorTypes - определения proto3 из скомпилированного FileDescriptorSet,
+// например из protoc -o out.pb --include_imports или buf build -o out.pb.
+type descriptorTypes struct {
+	files *protoregistry.Files
+}
+
+// LoadDescriptorSet читает FileDescriptorSet. Набор должен содержать все импорты,
+// кроме well-known types - они встроены.
+func LoadDescriptorSet(fileName string) (Registry, error) {
+	data, err := os.ReadFile(fileName)
+	if err != nil {
+		return nil, fmt.Errorf("can not read descriptor set \"%s\": %w", fileName, err)
+	}
+
+	var descriptorSet descriptorpb.FileDescriptorSet
+	err = proto.Unmarshal(data, &descriptorSet)
+	if err != nil {
+		return nil, fmt.Errorf("can not unmarshal descriptor set \"%s\": %w", fileName, err)
+	}
+
+	addWellKnownFiles(&descriptorSet)
+	files, err := protodesc.NewFiles(&descriptorSet)
+	if err != nil {
+		return nil, fmt.Errorf("invalid descriptor set \"%s\" (was it built with --include_imports?): %w",
+			fileName, err)
+	}
+	return NewRegistry(files), nil
+}
+
+// addWellKnownFiles добавляет в набор встроенные well-known types, которые в нём импортируются,
+// но которых в нём нет
+func addWellKnownFiles(descriptorSet *descriptorpb.FileDescriptorSet) {
+	included := map[string]bool{}
+	for _, file := range descriptorSet.File {
+		included[file.GetName()] = true
+	}
+	for _, file := range descriptorSet.File {
+		for _, dependency := range file.Dependency {
+			wellKnown, ok := wellKnownFiles[dependency]
+			if !ok || included[dependency] {
+				continue
+			}
+			included[dependency] = true
+			descriptorSet.File = append(descriptorSet.File, protodesc.ToFileDescriptorProto(wellKnown))
+		}
+	}
+}
+
+// NewRegistry возвращает определения proto3 из уже загруженных дескрипторов,
+// например из protoregistry.GlobalFiles сервиса, в который встроена проверка.
+func NewRegistry(files *protoregistry.Files) Registry {
+	return &descriptorTypes{files: files}
+}
+
+func (d *descriptorTypes) hasServices() bool {
+	found := false
+	d.files.RangeFiles(func(file protoreflect.FileDescriptor) bool {
+		found = file.Services().Len() != 0
+		return !found
+	})
+	return found
+}
+
+func (d *descriptorTypes) resolveRPC(pkg string, service string, method string) (*protoRPC, error) {
+	var services []protoreflect.ServiceDescriptor
+	d.files.RangeFiles(func(file protoreflect.FileDescriptor) bool {
+		if pkg != "" && string(file.Package()) != pkg {
+			return true
+		}
+		if serviceDescriptor := file.Services().ByName(protoreflect.Name(service)); serviceDescriptor != nil {
+			services = append(services, serviceDescriptor)
+		}
+		return true
+	})
+
+	switch len(services) {
+	case 0:
+		return nil, errServiceNotFound
+	case 1:
+	default:
+		candidates := make([]string, 0, len(services))
+		for _, serviceDescriptor := range services {
+			candidates = append(candidates, string(serviceDescriptor.FullName()))
+		}
+		sort.Strings(candidates)
+		return nil, &ambiguousSymbolError{name: service, candidates: candidates}
+	}
+
+	methodDescriptor := services[0].Methods().ByName(protoreflect.Name(method))
+	if methodDescriptor == nil {
+		return nil, errMethodNotFound
+	}
+	return &protoRPC{
+		Request:  "." + string(methodDescriptor.Input().FullName()),
+		Response: "." + string(methodDescriptor.Output().FullName()),
+		Scope:    string(services[0].ParentFile().Package()),
+	}, nil
+}
+
+func (d *descriptorTypes) resolveType(name string, scope string) (string, bool, error) {
+	fullName, err := resolveProtoName(name, scope, d.isType)
+	if err != nil {
+		return "", false, err
+	}
+	return fullName, d.isEnum(fullName), nil
+}
+
+func (d *descriptorTypes) findType(name string) (string, bool, error) {
+	fullName, err := findProtoName(name, d.isType, d.typeNames)
+	if err != nil {
+		return "", false, err
+	}
+	return fullName, d.isEnum(fullName), nil
+}
+
+// isEnum сообщает, что полное имя fullName - это enum
+func (d *descriptorTypes) isEnum(fullName string) bool {
+	descriptor, _ := d.files.FindDescriptorByName(protoreflect.FullName(fullName))
+	_, ok := descriptor.(protoreflect.EnumDescriptor)
+	return ok
+}
+
+// isType сообщает, объявлено ли сообщение или enum с полным именем fullName
+func (d *descriptorTypes) isType(fullName string) bool {
+	descriptor, err := d.files.FindDescriptorByName(protoreflect.FullName(fullName))
+	if err != nil {
+		return false
+	}
+	switch descriptor := descriptor.(type) {
+	case protoreflect.MessageDescriptor:
+		return !descriptor.IsMapEntry()
+	case protoreflect.EnumDescriptor:
+		return true
+	}
+	return false
+}
+
+// typeNames возвращает полные имена всех сообщений и enum, включая вложенные
+func (d *descriptorTypes) typeNames() []string {
+	var names []string
+	d.files.RangeFiles(func(file protoreflect.FileDescriptor) bool {
+		names = appendTypeNames(names, file.Messages(), file.Enums())
+		return true
+	})
+	return names
+}
+
+// appendTypeNames рекурсивно добавляет к names полные имена messages и enums.
+// Сообщения map entry, которые protoc создаёт для map полей, пропускаются.
+func appendTypeNames(names []string, messages protoreflect.MessageDescriptors, enums protoreflect.EnumDescriptors) []string {
+	for i := 0; i < messages.Len(); i++ {
+		message := messages.Get(i)
+		if message.IsMapEntry() {
+			continue
+		}
+		names = append(names, string(message.FullName()))
+		names = appendTypeNames(names, message.Messages(), message.Enums())
+	}
+	for i := 0; i < enums.Len(); i++ {
+		names = append(names, string(enums.Get(i).FullName()))
+	}
+	return names
+}
+
+func (d *descriptorTypes) messageFields(fullName string) ([]protoField, error) {
+	descriptor, err := d.files.FindDescriptorByName(protoreflect.FullName(fullName))
+	if err != nil {
+		return nil, errSymbolNotFound
+	}
+	message, ok := descriptor.(protoreflect.MessageDescriptor)
+	if !ok {
+		return nil, errSymbolNotFound
+	}
+
+	var fields []protoField
+	for i := 0; i < message.Fields().Len(); i++ {
+		field := message.Fields().Get(i)
+
+		result := protoField{Name: string(field.Name()), JSONName: field.JSONName()}
+		if field.IsMap() {
+			result.Type = fieldType{
+				Name:   descriptorTypeName(field.MapValue()),
+				MapKey: descriptorTypeName(field.MapKey()),
+			}
+		} else {
+			result.Type = fieldType{
+				Name:     descriptorTypeName(field),
+				Repeated: field.Cardinality() == protoreflect.Repeated,
+			}
+		}
+		// synthetic oneof - это proto3 optional поле, а не настоящий oneof
+		if oneof := field.ContainingOneof(); oneof != nil && !oneof.IsSynthetic() {
+			result.Oneof = string(oneof.Name())
+		}
+		fields = append(fields, result)
+	}
+	return fields, nil
+}
+
+func (d *descriptorTypes) enumValues(fullName string) ([]string, error) {
+	descriptor, err := d.files.FindDescriptorByName(protoreflect.FullName(fullName))
+	if err != nil {
+		return nil, errSymbolNotFound
+	}
+	enum, ok := descriptor.(protoreflect.EnumDescriptor)
+	if !ok {
+		return nil, errSymbolNotFound
+	}
+
+	values := make([]string, 0, enum.Values().Len())
+	for i := 0; i < enum.Values().Len(); i++ {
+		values = append(values, string(enum.Values().Get(i).Name()))
+	}
+	return values, nil
+}
+
+// descriptorTypeName возвращает скалярный тип поля или полное имя его сообщения или enum
+func descriptorTypeName(field protoreflect.FieldDescriptor) string {
+	switch field.Kind() {
+	case protoreflect.MessageKind, protoreflect.GroupKind:
+		return string(field.Message().FullName())
+	case protoreflect.EnumKind:
+		return string(field.Enum().FullName())
+	}
+	return field.Kind().String()
+}
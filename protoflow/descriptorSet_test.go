@@ -0,0 +1,126 @@
+package protoflow
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/descriptorpb"
+)
+
+const descriptorTestProto = `syntax = "proto3";
+
+package example.alerts;
+
+message GetAlert {
+    uint64 id = 1;
+}
+
+message Alert {
+    uint64 id = 1;
+    int32 severity = 2;
+    repeated string ip = 3;
+}
+
+message Block {
+    int64 severity = 1;
+    repeated string ip = 2;
+}
+
+service Alerts {
+    rpc Get (GetAlert) returns (Alert) {}
+    rpc Block (Block) returns (GetAlert) {}
+}
+`
+
+// descriptorTestSet - те же определения, что в descriptorTestProto
+func descriptorTestSet() *descriptorpb.FileDescriptorSet {
+	field := func(name string, number int32, fieldType descriptorpb.FieldDescriptorProto_Type,
+		label descriptorpb.FieldDescriptorProto_Label) *descriptorpb.FieldDescriptorProto {
+		return &descriptorpb.FieldDescriptorProto{
+			Name:     proto.String(name),
+			JsonName: proto.String(jsonFieldName(name)),
+			Number:   proto.Int32(number),
+			Label:    label.Enum(),
+			Type:     fieldType.Enum(),
+		}
+	}
+	optional, repeated := descriptorpb.FieldDescriptorProto_LABEL_OPTIONAL, descriptorpb.FieldDescriptorProto_LABEL_REPEATED
+	method := func(name string, input string, output string) *descriptorpb.MethodDescriptorProto {
+		return &descriptorpb.MethodDescriptorProto{
+			Name:       proto.String(name),
+			InputType:  proto.String(".example.alerts." + input),
+			OutputType: proto.String(".example.alerts." + output),
+		}
+	}
+
+	return &descriptorpb.FileDescriptorSet{File: []*descriptorpb.FileDescriptorProto{{
+		Name:    proto.String("alerts.proto"),
+		Package: proto.String("example.alerts"),
+		Syntax:  proto.String("proto3"),
+		MessageType: []*descriptorpb.DescriptorProto{
+			{Name: proto.String("GetAlert"), Field: []*descriptorpb.FieldDescriptorProto{
+				field("id", 1, descriptorpb.FieldDescriptorProto_TYPE_UINT64, optional),
+			}},
+			{Name: proto.String("Alert"), Field: []*descriptorpb.FieldDescriptorProto{
+				field("id", 1, descriptorpb.FieldDescriptorProto_TYPE_UINT64, optional),
+				field("severity", 2, descriptorpb.FieldDescriptorProto_TYPE_INT32, optional),
+				field("ip", 3, descriptorpb.FieldDescriptorProto_TYPE_STRING, repeated),
+			}},
+			{Name: proto.String("Block"), Field: []*descriptorpb.FieldDescriptorProto{
+				field("severity", 1, descriptorpb.FieldDescriptorProto_TYPE_INT64, optional),
+				field("ip", 2, descriptorpb.FieldDescriptorProto_TYPE_STRING, repeated),
+			}},
+		},
+		Service: []*descriptorpb.ServiceDescriptorProto{{
+			Name:   proto.String("Alerts"),
+			Method: []*descriptorpb.MethodDescriptorProto{method("Get", "GetAlert", "Alert"), method("Block", "Block", "GetAlert")},
+		}},
+	}}}
+}
+
+func TestCheck_DescriptorSetMatchesProtoFiles(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	protoFile := filepath.Join(dir, "alerts.proto")
+	require.NoError(t, os.WriteFile(protoFile, []byte(descriptorTestProto), 0o600))
+	protoRegistry, err := LoadProtoFiles(protoFile, nil, ParseOptions{})
+	require.NoError(t, err)
+
+	data, err := proto.Marshal(descriptorTestSet())
+	require.NoError(t, err)
+	descriptorSetFile := filepath.Join(dir, "alerts.pb")
+	require.NoError(t, os.WriteFile(descriptorSetFile, data, 0o600))
+	descriptorRegistry, err := LoadDescriptorSet(descriptorSetFile)
+	require.NoError(t, err)
+
+	definition := []byte(`{
+		"StartAt": "Get",
+		"States": {
+			"Get": {"Type": "Task", "Resource": "grpc:127.0.0.1:5678/example.alerts.Alerts/Get", "Next": "Block"},
+			"Block": {"Type": "Task", "Resource": "grpc:127.0.0.1:5678/example.alerts.Alerts/Block", "End": true}
+		}
+	}`)
+	expected := map[Compatibility][]Issue{
+		Lenient: {},
+		Strict: {
+			{Code: FieldTypeMismatch, State: "Block", Path: []string{"Get", "Block"},
+				MessageType: "example.alerts.Block", Field: "severity", Expected: "int64", Actual: "int32",
+				Origin: Origin{Kind: OriginResponse, State: "Get", Step: 1, Message: "example.alerts.Alert",
+					Field: "severity"}},
+		},
+	}
+
+	registries := map[string]Registry{"proto files": protoRegistry, "descriptor set": descriptorRegistry}
+	for registryName, registry := range registries {
+		for mode, issues := range expected {
+			actual, err := Check(definition, registry, Options{InputType: "GetAlert", Compatibility: mode})
+			require.NoError(t, err, registryName)
+			assert.Equal(t, issues, actual, registryName)
+		}
+	}
+}
@@ -0,0 +1,338 @@
+package protoflow
+
+import (
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// intrinsicResults - типы результатов встроенных функций States.*.
+// Результат остальных функций считается значением неизвестного типа.
+var intrinsicResults = map[string]string{
+	"States.Format":       "string",
+	"States.UUID":         "string",
+	"States.JsonToString": "string",
+	"States.Base64Encode": "string",
+	"States.Base64Decode": "string",
+	"States.Hash":         "string",
+	"States.ArrayLength":  "int32",
+	"States.MathAdd":      "int64",
+	"States.MathRandom":   "int64",
+}
+
+// stateResults возвращает возможные результаты Pass, Task, Parallel или Map
+// до применения ResultSelector и ResultPath. input - вход состояния после InputPath.
+func (c *flowChecker) stateResults(stateType string, step map[string]interface{}, input *stateValue, path []string) []*stateValue {
+	if stateType == "Map" {
+		return c.walkMap(step, input, path)
+	}
+
+	parameters := c.applyTemplate(step, "Parameters", input, newAnyValue(), path)
+	switch stateType {
+	case "Pass":
+		if result, ok := step["Result"]; ok {
+			return []*stateValue{newLiteralValue(result, playbookOrigin(path, "Result"))}
+		}
+		return []*stateValue{parameters}
+	case "Task":
+		result := c.checkTask(step, parameters, path)
+		if result == nil {
+			return nil
+		}
+		return []*stateValue{result}
+	}
+	return c.walkParallel(step, parameters, path)
+}
+
+// applyResult применяет к результату состояния ResultSelector, кладёт его во вход
+// состояния по ResultPath и выбирает выход по OutputPath.
+func (c *flowChecker) applyResult(step map[string]interface{}, input *stateValue, result *stateValue, path []string) *stateValue {
+	result = c.applyTemplate(step, "ResultSelector", result, newAnyValue(), path)
+	return c.applyPath(step, "OutputPath", c.applyResultPath(step, input, result, path), path)
+}
+
+// applyPath применяет InputPath или OutputPath. null вместо пути даёт пустой объект.
+func (c *flowChecker) applyPath(step map[string]interface{}, field string, value *stateValue, path []string) *stateValue {
+	rawPath, ok := step[field]
+	if !ok {
+		return value
+	}
+	if rawPath == nil {
+		return newObjectValue(map[string]*stateValue{})
+	}
+	jsonPath, _ := rawPath.(string)
+	return c.readPath(value, nil, jsonPath, field, path)
+}
+
+// applyResultPath кладёт результат во вход состояния по ResultPath.
+// Без ResultPath результат заменяет вход, null вместо пути отбрасывает результат.
+func (c *flowChecker) applyResultPath(step map[string]interface{}, input *stateValue, result *stateValue, path []string) *stateValue {
+	rawPath, ok := step["ResultPath"]
+	if !ok {
+		return result
+	}
+	if rawPath == nil {
+		return input
+	}
+	jsonPath, _ := rawPath.(string)
+	root, steps, err := parseJSONPath(jsonPath)
+	if err == nil && root != "$" {
+		err = errContextPath
+	}
+	if err != nil {
+		c.problems.Append(unsupportedJSONPath(path, "ResultPath", jsonPath, err))
+		return newAnyValue()
+	}
+
+	merged, mismatch := c.setPath(input, steps, result, "$", path)
+	if mismatch != nil {
+		c.problems.Append(pathDoesNotMatch(path, "ResultPath", jsonPath, mismatch))
+		return newAnyValue()
+	}
+	return merged
+}
+
+// applyTemplate строит значение по шаблону field: Parameters, ResultSelector или ItemSelector.
+// Поля с суффиксом ".$" берутся по JSONPath из input или из контекста $$,
+// либо вычисляются встроенной функцией. Без шаблона возвращает input.
+func (c *flowChecker) applyTemplate(
+	step map[string]interface{},
+	field string,
+	input *stateValue,
+	context *stateValue,
+	path []string,
+) *stateValue {
+	template, ok := step[field]
+	if !ok {
+		return input
+	}
+	return c.buildTemplate(template, input, context, field, playbookOrigin(path, field), path)
+}
+
+// buildTemplate строит значение по части шаблона field. origin - где в плейбуке записана эта часть.
+func (c *flowChecker) buildTemplate(
+	template interface{},
+	input *stateValue,
+	context *stateValue,
+	field string,
+	origin Origin,
+	path []string,
+) *stateValue {
+	switch template := template.(type) {
+	case map[string]interface{}:
+		keys := make([]string, 0, len(template))
+		for key := range template {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+
+		fields := make(map[string]*stateValue, len(template))
+		for _, key := range keys {
+			if name := strings.TrimSuffix(key, ".$"); name != key {
+				reference, _ := template[key].(string)
+				fields[name] = c.resolveReference(reference, input, context, field, origin.field(name), path)
+				continue
+			}
+			fields[key] = c.buildTemplate(template[key], input, context, field, origin.field(key), path)
+		}
+		return newObjectValue(fields).withOrigin(origin)
+	case []interface{}:
+		items := make([]*stateValue, 0, len(template))
+		for i, item := range template {
+			items = append(items, c.buildTemplate(item, input, context, field, origin.field(strconv.Itoa(i)), path))
+		}
+		return newArrayValue(items).withOrigin(origin)
+	}
+	return newLiteralValue(template, origin)
+}
+
+// playbookOrigin - происхождение значений из поля attribute состояния, которым заканчивается path
+func playbookOrigin(path []string, attribute string) Origin {
+	origin := stateOrigin(OriginPlaybook, path)
+	origin.Attribute = attribute
+	return origin
+}
+
+// resolveReference возвращает значение поля шаблона с суффиксом ".$".
+// Результат встроенной функции получает происхождение origin, значение по JSONPath - своё.
+func (c *flowChecker) resolveReference(
+	reference string,
+	input *stateValue,
+	context *stateValue,
+	field string,
+	origin Origin,
+	path []string,
+) *stateValue {
+	if strings.HasPrefix(reference, "States.") {
+		name := reference
+		if i := strings.IndexByte(reference, '('); i >= 0 {
+			name = reference[:i]
+		}
+		if result, ok := intrinsicResults[name]; ok {
+			return newProtoValue(fieldType{Name: result}).withOrigin(origin)
+		}
+		return newAnyValue().withOrigin(origin)
+	}
+	return c.readPath(input, context, reference, field, path)
+}
+
+// readPath возвращает часть value или контекста context по JSONPath из поля field.
+// Если путь не подходит к значению, добавляет ошибку и возвращает значение неизвестного типа.
+func (c *flowChecker) readPath(
+	value *stateValue,
+	context *stateValue,
+	jsonPath string,
+	field string,
+	path []string,
+) *stateValue {
+	root, steps, err := parseJSONPath(jsonPath)
+	if err != nil {
+		c.problems.Append(unsupportedJSONPath(path, field, jsonPath, err))
+		return newAnyValue()
+	}
+	if root == "$$" {
+		value = context
+		if value == nil {
+			c.problems.Append(unsupportedJSONPath(path, field, jsonPath, errContextPath))
+			return newAnyValue()
+		}
+	}
+
+	selected, mismatch := c.selectPath(value, steps, root, path)
+	if mismatch != nil {
+		c.problems.Append(pathDoesNotMatch(path, field, jsonPath, mismatch))
+		return newAnyValue()
+	}
+	return selected
+}
+
+// selectPath возвращает часть значения по шагам JSONPath. prefix - путь до value.
+// Шаг [*] даёт массив из частей всех элементов.
+func (c *flowChecker) selectPath(value *stateValue, steps []pathStep, prefix string, path []string) (*stateValue, *pathError) {
+	for i, step := range steps {
+		value = c.expand(value, path)
+		if value.kind == anyKind {
+			return value, nil
+		}
+		notFound := &pathError{prefix: prefix, step: step, value: value}
+		prefix += step.String()
+
+		switch {
+		case step.wildcard && value.kind == listKind:
+			elem, mismatch := c.selectPath(value.elem, steps[i+1:], prefix, path)
+			if mismatch != nil {
+				return nil, mismatch
+			}
+			return newListValue(elem), nil
+		case step.wildcard && value.kind == tupleKind:
+			items := make([]*stateValue, 0, len(value.items))
+			for _, item := range value.items {
+				selected, mismatch := c.selectPath(item, steps[i+1:], prefix, path)
+				if mismatch != nil {
+					return nil, mismatch
+				}
+				items = append(items, selected)
+			}
+			return newArrayValue(items), nil
+		case step.isIndex && value.kind == listKind:
+			value = value.elem
+		case step.isIndex && value.kind == tupleKind && step.index < len(value.items):
+			value = value.items[step.index]
+		case step.field != "" && value.kind == objectKind && value.fields[value.fieldName(step.field)] != nil:
+			value = value.fields[value.fieldName(step.field)]
+		case step.field != "" && value.kind == protoKind && value.proto.MapKey != "":
+			value = newProtoValue(fieldType{Name: value.proto.Name}).withOrigin(value.origin.field(step.field))
+		default:
+			return nil, notFound
+		}
+	}
+	return value, nil
+}
+
+// setPath возвращает копию value, в которой по шагам JSONPath лежит result.
+// Недостающие объекты на пути создаются, как это делает ResultPath.
+func (c *flowChecker) setPath(
+	value *stateValue,
+	steps []pathStep,
+	result *stateValue,
+	prefix string,
+	path []string,
+) (*stateValue, *pathError) {
+	if len(steps) == 0 {
+		return result, nil
+	}
+	value = c.expand(value, path)
+	if value.kind == anyKind {
+		return value, nil
+	}
+	step := steps[0]
+	if value.kind != objectKind || step.field == "" {
+		return nil, &pathError{prefix: prefix, step: step, value: value}
+	}
+
+	name := value.fieldName(step.field)
+	child, ok := value.fields[name]
+	switch {
+	case !ok:
+		child = newObjectValue(map[string]*stateValue{})
+	case len(steps) == 1 && c.usage != nil:
+		c.usage.overwrite(child, prefix+step.String(), path)
+	}
+	child, mismatch := c.setPath(child, steps[1:], result, prefix+step.String(), path)
+	if mismatch != nil {
+		return nil, mismatch
+	}
+	return value.withField(name, child), nil
+}
+
+// expand раскрывает значение поля proto3 так, как оно выглядит в proto3 JSON: сообщение -
+// в объект с его полями, repeated поле - в массив. Скаляры, enum, map и well-known types,
+// которые записываются строкой или скаляром, остаются как есть, Struct и Value - любое значение.
+func (c *flowChecker) expand(value *stateValue, path []string) *stateValue {
+	if value.kind != protoKind || value.proto.MapKey != "" {
+		return value
+	}
+	if value.proto.Repeated {
+		return newListValue(newProtoValue(fieldType{Name: value.proto.Name}).withOrigin(value.origin)).withOrigin(value.origin)
+	}
+	if _, ok := protoScalarTypes[value.proto.Name]; ok {
+		return value
+	}
+
+	fullName, isEnum, err := c.types.resolveType(value.proto.Name, "")
+	if err != nil {
+		c.reportLookupError(path, value.proto.Name, err)
+		return newAnyValue()
+	}
+	switch {
+	case isEnum:
+		return value
+	case fullName == wellKnownStruct || fullName == wellKnownValue || fullName == wellKnownAny:
+		// в JSON это произвольный объект или значение
+		return newAnyValue().withOrigin(value.origin)
+	case fullName == wellKnownListValue:
+		return newListValue(newAnyValue().withOrigin(value.origin)).withOrigin(value.origin)
+	case hasSpecialJSON(fullName):
+		// Timestamp, Duration, FieldMask и обёртки записываются в JSON строкой или скаляром
+		return value
+	}
+	fields, err := c.types.messageFields(fullName)
+	if err != nil {
+		c.reportLookupError(path, fullName, err)
+		return newAnyValue()
+	}
+
+	object := newObjectValue(make(map[string]*stateValue, len(fields)))
+	for _, field := range fields {
+		object.fields[field.Name] = newProtoValue(field.Type).withOrigin(value.origin.field(field.Name))
+		if field.JSONName != "" && field.JSONName != field.Name {
+			if object.jsonNames == nil {
+				object.jsonNames = map[string]string{}
+			}
+			object.jsonNames[field.JSONName] = field.Name
+		}
+	}
+	object.message = fullName
+	object.origin = value.origin
+	return object
+}
@@ -0,0 +1,361 @@
+package protoflow
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Code - вид проблемы передачи данных
+type Code string
+
+const (
+	// StartAtMissing - у плейбука нет StartAt
+	StartAtMissing Code = "StartAtMissing"
+	// StatesMissing - у плейбука нет States
+	StatesMissing Code = "StatesMissing"
+	// ServicesMissing - в proto3 определениях нет ни одного сервиса
+	ServicesMissing Code = "ServicesMissing"
+	// InputTypeNotMessage - тип входа плейбука - скаляр или enum. MessageType - тип входа.
+	InputTypeNotMessage Code = "InputTypeNotMessage"
+	// StateNotFound - переход в несуществующее состояние. Field - имя состояния,
+	// State - состояние, из которого переход, или пустая строка для StartAt.
+	StateNotFound Code = "StateNotFound"
+	// TransitionMissing - у состояния нет Next и End или у обработчика Catch нет Next.
+	// Attribute - "Next" или обработчик, например "Catch[1]".
+	TransitionMissing Code = "TransitionMissing"
+	// StateTypeUnsupported - тип состояния не поддерживается. Actual - тип.
+	StateTypeUnsupported Code = "StateTypeUnsupported"
+	// NestedMachineInvalid - у ветки Parallel или Iterator Map нет StartAt или States.
+	// Attribute - "Branches[i]" или "Iterator".
+	NestedMachineInvalid Code = "NestedMachineInvalid"
+	// TooManyInputs - на вход состояния приходит слишком много разных значений, его проверка остановлена
+	TooManyInputs Code = "TooManyInputs"
+	// ResourceInvalid - Resource задачи не разбирается. Actual - Resource, Reason - ошибка разбора.
+	ResourceInvalid Code = "ResourceInvalid"
+	// ServiceNotFound - сервиса из Resource нет. MessageType - полное имя сервиса.
+	ServiceNotFound Code = "ServiceNotFound"
+	// RPCNotFound - у сервиса нет метода из Resource. MessageType - сервис, Field - метод.
+	RPCNotFound Code = "RPCNotFound"
+	// MessageNotFound - сообщение или enum не найдены. MessageType - имя так, как на него ссылаются.
+	MessageNotFound Code = "MessageNotFound"
+	// AmbiguousReference - ссылка подходит под несколько определений.
+	// MessageType - ссылка, Candidates - подходящие определения.
+	AmbiguousReference Code = "AmbiguousReference"
+	// InputNotObject - на вход задачи передаётся не объект. Actual - форма входа.
+	InputNotObject Code = "InputNotObject"
+	// FieldMissing - во входе задачи нет поля request сообщения. MessageType - request, Field - поле.
+	FieldMissing Code = "FieldMissing"
+	// OneofMissing - во входе задачи нет ни одного поля oneof.
+	// MessageType - request, Field - oneof, Candidates - поля oneof.
+	OneofMissing Code = "OneofMissing"
+	// FieldTypeMismatch - тип поля во входе задачи не совместим с полем request сообщения.
+	// MessageType - request, Field - поле, Expected и Actual - типы.
+	FieldTypeMismatch Code = "FieldTypeMismatch"
+	// PathUnsupported - JSONPath не поддерживается. Attribute - поле состояния, например
+	// "InputPath", JSONPath - путь, Reason - почему он не поддерживается.
+	PathUnsupported Code = "PathUnsupported"
+	// PathNotFound - JSONPath не подходит к значению. Attribute - поле состояния, JSONPath - путь,
+	// Field - первая часть пути, которой нет, Actual - форма значения, в котором её нет.
+	PathNotFound Code = "PathNotFound"
+	// ItemsNotArray - ItemsPath указывает не на массив. JSONPath - ItemsPath, Actual - форма значения.
+	ItemsNotArray Code = "ItemsNotArray"
+)
+
+// OriginKind - откуда взялось значение во входе состояния
+type OriginKind string
+
+const (
+	// OriginInput - вход плейбука. Message - тип входа.
+	OriginInput OriginKind = "Input"
+	// OriginResponse - response задачи State. Message - response сообщение.
+	OriginResponse OriginKind = "Response"
+	// OriginPlaybook - значение записано в плейбуке: Attribute - Result, Parameters,
+	// ResultSelector или ItemSelector состояния State.
+	OriginPlaybook OriginKind = "Playbook"
+	// OriginError - объект ошибки {Error, Cause}, который обработчик Attribute состояния State
+	// кладёт по своему ResultPath.
+	OriginError OriginKind = "Error"
+)
+
+// Origin - происхождение значения. Пустой Kind - происхождение неизвестно,
+// например у объекта, собранного из полей разного происхождения.
+type Origin struct {
+	Kind OriginKind
+	// State - состояние, в котором значение появилось. Пустое для входа плейбука.
+	State string
+	// Step - номер State в Path проблемы, начиная с 1
+	Step int
+	// Message - сообщение proto3, из которого взято значение
+	Message string
+	// Attribute - поле состояния ASL, в котором записано значение
+	Attribute string
+	// Field - путь до значения внутри Message или Attribute, например "incident.id"
+	Field string
+}
+
+// field возвращает происхождение поля name значения с происхождением o
+func (o Origin) field(name string) Origin {
+	if o.Kind == "" {
+		return o
+	}
+	if o.Field != "" {
+		name = o.Field + "." + name
+	}
+	o.Field = name
+	return o
+}
+
+// stateOrigin возвращает происхождение kind значения, которое появилось
+// в состоянии, которым заканчивается path
+func stateOrigin(kind OriginKind, path []string) Origin {
+	return Origin{Kind: kind, State: path[len(path)-1], Step: len(path)}
+}
+
+// Issue - проблема передачи данных между состояниями плейбука.
+// Какие поля заполнены, зависит от Code.
+type Issue struct {
+	Code Code
+	// State - состояние, в котором найдена проблема
+	State string
+	// Path - состояния от StartAt до State по пути, на котором найдена проблема, вместе
+	// с переходами во вложенные автоматы и обработчики: "Branches[0]", "Iterator", "Catch[1]"
+	Path []string
+	// MessageType - сообщение, enum или сервис proto3
+	MessageType string
+	// Field - поле сообщения, oneof, rpc метод или состояние
+	Field string
+	// Expected - ожидаемый тип
+	Expected string
+	// Actual - переданный тип или форма значения
+	Actual string
+	// Attribute - поле состояния ASL, например "ResultPath", "Catch[1]" или "Branches[0]"
+	Attribute string
+	// JSONPath - путь из Attribute
+	JSONPath string
+	// Reason - пояснение, например ошибка разбора
+	Reason string
+	// Candidates - подходящие определения или поля oneof
+	Candidates []string
+	// Origin - происхождение значения, о котором проблема: поля с неподходящим типом,
+	// входа задачи, в котором нет поля, или значения, к которому не подошёл JSONPath
+	Origin Origin
+}
+
+// issueList - проблемы в порядке их нахождения.
+// Одинаковые проблемы, найденные несколько раз, в том числе на разных путях
+// до одного и того же состояния, хранятся один раз с путём, на котором они найдены впервые.
+type issueList struct {
+	issues []Issue
+	seen   map[string]struct{}
+}
+
+func newIssueList() *issueList {
+	return &issueList{
+		issues: []Issue{},
+		seen:   map[string]struct{}{},
+	}
+}
+
+func (l *issueList) Append(issue Issue) {
+	key := issueKey(issue)
+	if _, ok := l.seen[key]; ok {
+		return
+	}
+	l.seen[key] = struct{}{}
+	l.issues = append(l.issues, issue)
+}
+
+// issueKey возвращает ключ, одинаковый у проблем, которые отличаются только путём до состояния
+func issueKey(issue Issue) string {
+	issue.Path = stateLocation(issue.Path)
+	issue.Origin.Step = 0
+	return fmt.Sprintf("%#v", issue)
+}
+
+// stateLocation возвращает положение последнего состояния path в плейбуке:
+// вложенные автоматы, в которых оно объявлено, и само состояние
+func stateLocation(path []string) []string {
+	var location []string
+	for i, element := range path {
+		if i > 0 && (strings.HasPrefix(element, "Branches[") || element == "Iterator") {
+			location = append(location, path[i-1], element)
+		}
+	}
+	if len(path) != 0 {
+		location = append(location, path[len(path)-1])
+	}
+	return location
+}
+
+func (l *issueList) Len() int {
+	return len(l.issues)
+}
+
+func (l *issueList) GetIssues() []Issue {
+	return l.issues
+}
+
+// stateIssue возвращает проблему состояния, которым заканчивается path
+func stateIssue(code Code, path []string) Issue {
+	issue := Issue{Code: code, Path: path}
+	if len(path) != 0 {
+		issue.State = path[len(path)-1]
+	}
+	return issue
+}
+
+func startException() Issue {
+	return Issue{Code: StartAtMissing}
+}
+
+func emptyStates() Issue {
+	return Issue{Code: StatesMissing}
+}
+
+func notFindServiceBody() Issue {
+	return Issue{Code: ServicesMissing}
+}
+
+func inputTypeIsNotMessage(inputType string) Issue {
+	return Issue{Code: InputTypeNotMessage, MessageType: inputType}
+}
+
+func invalidResource(path []string, resource string, err error) Issue {
+	issue := stateIssue(ResourceInvalid, path)
+	issue.Actual = resource
+	issue.Reason = err.Error()
+	return issue
+}
+
+func serviceDoesNotExist(path []string, service string) Issue {
+	issue := stateIssue(ServiceNotFound, path)
+	issue.MessageType = service
+	return issue
+}
+
+func rpcMethodDoesNotExist(path []string, service string, method string) Issue {
+	issue := stateIssue(RPCNotFound, path)
+	issue.MessageType = service
+	issue.Field = method
+	return issue
+}
+
+func messageBodyDoesNotExist(path []string, messageStructName string) Issue {
+	issue := stateIssue(MessageNotFound, path)
+	issue.MessageType = messageStructName
+	return issue
+}
+
+func ambiguousReference(path []string, name string, candidates []string) Issue {
+	issue := stateIssue(AmbiguousReference, path)
+	issue.MessageType = name
+	issue.Candidates = candidates
+	return issue
+}
+
+// stateDoesNotExist - StartAt автомата по положению location указывает на несуществующее состояние
+func stateDoesNotExist(location []string, target string) Issue {
+	return Issue{Code: StateNotFound, Path: location, Field: target, Attribute: "StartAt"}
+}
+
+func transitionToMissingState(path []string, target string) Issue {
+	issue := stateIssue(StateNotFound, path)
+	issue.Field = target
+	return issue
+}
+
+func tooManyStateInputs(path []string) Issue {
+	return stateIssue(TooManyInputs, path)
+}
+
+func stateHasNoTransition(path []string) Issue {
+	issue := stateIssue(TransitionMissing, path)
+	issue.Attribute = "Next"
+	return issue
+}
+
+func catcherHasNoTransition(path []string, catcher int) Issue {
+	issue := stateIssue(TransitionMissing, path)
+	issue.Attribute = "Catch[" + strconv.Itoa(catcher) + "]"
+	return issue
+}
+
+func unsupportedStateType(path []string, stateType string) Issue {
+	issue := stateIssue(StateTypeUnsupported, path)
+	issue.Actual = stateType
+	return issue
+}
+
+func invalidParallelBranch(path []string, branch int) Issue {
+	issue := stateIssue(NestedMachineInvalid, path)
+	issue.Attribute = "Branches[" + strconv.Itoa(branch) + "]"
+	return issue
+}
+
+func invalidMapIterator(path []string) Issue {
+	issue := stateIssue(NestedMachineInvalid, path)
+	issue.Attribute = "Iterator"
+	return issue
+}
+
+func unsupportedJSONPath(path []string, attribute string, jsonPath string, err error) Issue {
+	issue := stateIssue(PathUnsupported, path)
+	issue.Attribute = attribute
+	issue.JSONPath = jsonPath
+	issue.Reason = err.Error()
+	return issue
+}
+
+func pathDoesNotMatch(path []string, attribute string, jsonPath string, err *pathError) Issue {
+	issue := stateIssue(PathNotFound, path)
+	issue.Attribute = attribute
+	issue.JSONPath = jsonPath
+	issue.Field = err.prefix + err.step.String()
+	issue.Actual = err.value.String()
+	issue.Origin = err.value.origin
+	return issue
+}
+
+func itemsPathIsNotArray(path []string, itemsPath string, items *stateValue) Issue {
+	issue := stateIssue(ItemsNotArray, path)
+	issue.Attribute = "ItemsPath"
+	issue.JSONPath = itemsPath
+	issue.Actual = items.String()
+	issue.Origin = items.origin
+	return issue
+}
+
+func inputIsNotObject(path []string, input *stateValue) Issue {
+	issue := stateIssue(InputNotObject, path)
+	issue.Actual = input.String()
+	issue.Origin = input.origin
+	return issue
+}
+
+func fieldDoesNotExist(path []string, field string, messageType string, input *stateValue) Issue {
+	issue := stateIssue(FieldMissing, path)
+	issue.MessageType = messageType
+	issue.Field = field
+	issue.Origin = input.origin
+	return issue
+}
+
+func oneofDoesNotExist(path []string, oneof string, members []string, messageType string, input *stateValue) Issue {
+	issue := stateIssue(OneofMissing, path)
+	issue.MessageType = messageType
+	issue.Field = oneof
+	issue.Candidates = members
+	issue.Origin = input.origin
+	return issue
+}
+
+func invalidFieldType(path []string, field string, actual *stateValue, expected string, messageType string) Issue {
+	issue := stateIssue(FieldTypeMismatch, path)
+	issue.MessageType = messageType
+	issue.Field = field
+	issue.Expected = expected
+	issue.Actual = actual.String()
+	issue.Origin = actual.origin
+	return issue
+}
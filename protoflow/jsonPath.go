@@ -0,0 +1,96 @@
+package protoflow
+
+import (
+	"errors"
+	"strconv"
+	"strings"
+)
+
+var errContextPath = errors.New("context object \"$$\" can not be used here")
+
+// pathStep - шаг JSONPath: поле объекта, элемент массива по индексу или все элементы массива
+type pathStep struct {
+	field    string
+	index    int
+	isIndex  bool
+	wildcard bool
+}
+
+// String возвращает шаг так, как он записывается в JSONPath, например ".id", "[0]" или "[*]"
+func (s pathStep) String() string {
+	switch {
+	case s.wildcard:
+		return "[*]"
+	case s.isIndex:
+		return "[" + strconv.Itoa(s.index) + "]"
+	}
+	return "." + s.field
+}
+
+// pathError - шаг JSONPath, которого нет в значении
+type pathError struct {
+	// prefix - часть пути до шага, например "$.incident"
+	prefix string
+	step   pathStep
+	// value - значение по prefix
+	value *stateValue
+}
+
+// parseJSONPath разбирает путь вида $.a.b[0]['c d'][*]. Возвращает корень пути -
+// "$" для входа состояния или "$$" для контекста - и шаги от него.
+// Рекурсивный спуск "..", фильтры и срезы не поддерживаются.
+func parseJSONPath(path string) (string, []pathStep, error) {
+	root := "$"
+	if strings.HasPrefix(path, "$$") {
+		root = "$$"
+	} else if !strings.HasPrefix(path, "$") {
+		return "", nil, errors.New("path must start with \"$\" or \"$$\"")
+	}
+
+	var steps []pathStep
+	rest := path[len(root):]
+	for rest != "" {
+		switch rest[0] {
+		case '.':
+			rest = rest[1:]
+			end := strings.IndexAny(rest, ".[")
+			if end < 0 {
+				end = len(rest)
+			}
+			if end == 0 {
+				return "", nil, errors.New("recursive descent \"..\" and empty field names are not supported")
+			}
+			steps = append(steps, pathStep{field: rest[:end]})
+			rest = rest[end:]
+		case '[':
+			end := strings.IndexByte(rest, ']')
+			if end < 0 {
+				return "", nil, errors.New("unclosed \"[\"")
+			}
+			step, err := parseBracketStep(rest[1:end])
+			if err != nil {
+				return "", nil, err
+			}
+			steps = append(steps, step)
+			rest = rest[end+1:]
+		default:
+			return "", nil, errors.New("unexpected \"" + rest[:1] + "\"")
+		}
+	}
+	return root, steps, nil
+}
+
+// parseBracketStep разбирает содержимое скобок: '*', индекс или имя поля в кавычках
+func parseBracketStep(selector string) (pathStep, error) {
+	if selector == "*" {
+		return pathStep{wildcard: true}, nil
+	}
+	if len(selector) >= 2 && (selector[0] == '\'' || selector[0] == '"') && selector[len(selector)-1] == selector[0] {
+		return pathStep{field: selector[1 : len(selector)-1]}, nil
+	}
+	index, err := strconv.Atoi(selector)
+	if err != nil || index < 0 {
+		return pathStep{}, errors.New("filters, slices and negative indexes are not supported: [" + selector + "]")
+	}
+	return pathStep{index: index, isIndex: true}, nil
+}
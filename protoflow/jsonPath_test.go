@@ -0,0 +1,43 @@
+package protoflow
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseJSONPath(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		path          string
+		expectedRoot  string
+		expectedSteps []pathStep
+		hasError      bool
+	}{
+		{path: "$", expectedRoot: "$"},
+		{path: "$.a.b", expectedRoot: "$", expectedSteps: []pathStep{{field: "a"}, {field: "b"}}},
+		{
+			path:          "$.a[0]['c d'][*]",
+			expectedRoot:  "$",
+			expectedSteps: []pathStep{{field: "a"}, {index: 0, isIndex: true}, {field: "c d"}, {wildcard: true}},
+		},
+		{path: "$$.Map.Item.Value", expectedRoot: "$$",
+			expectedSteps: []pathStep{{field: "Map"}, {field: "Item"}, {field: "Value"}}},
+		{path: "$..a", hasError: true},
+		{path: "$.a[?(@.b)]", hasError: true},
+		{path: "$.a[1:2]", hasError: true},
+		{path: "a.b", hasError: true},
+	}
+
+	for _, tc := range testCases {
+		root, steps, err := parseJSONPath(tc.path)
+		if tc.hasError {
+			assert.Error(t, err, tc.path)
+			continue
+		}
+		assert.NoError(t, err, tc.path)
+		assert.Equal(t, tc.expectedRoot, root, tc.path)
+		assert.Equal(t, tc.expectedSteps, steps, tc.path)
+	}
+}
@@ -0,0 +1,122 @@
+package protoflow
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"google.golang.org/protobuf/reflect/protoreflect"
+)
+
+// protoLoader загружает proto3 файл и все файлы, которые он импортирует, в одну таблицу символов
+type protoLoader struct {
+	includePaths []string
+	options      ParseOptions
+	symbols      *protoSymbols
+	// loaded - файлы по имени из import: true - загружен вместе с импортами, false - загружается
+	loaded map[string]bool
+	// stack - цепочка импортов до файла, который загружается сейчас
+	stack []string
+}
+
+// LoadProtoFiles разбирает protoFileName и транзитивно все его импорты.
+// Импорты ищутся в includePaths, а если они не заданы - в директории protoFileName.
+func LoadProtoFiles(protoFileName string, includePaths []string, options ParseOptions) (Registry, error) {
+	if len(includePaths) == 0 {
+		includePaths = []string{filepath.Dir(protoFileName)}
+	}
+	loader := &protoLoader{
+		includePaths: includePaths,
+		options:      options,
+		symbols:      newProtoSymbols(),
+		loaded:       map[string]bool{},
+	}
+
+	err := loader.load(loader.canonicalName(protoFileName), protoFileName)
+	if err != nil {
+		return nil, err
+	}
+	return loader.symbols, nil
+}
+
+func (l *protoLoader) load(name string, path string) error {
+	if done, seen := l.loaded[name]; seen {
+		if done {
+			return nil
+		}
+		for i, stackName := range l.stack {
+			if stackName == name {
+				cycle := append(l.stack[i:len(l.stack):len(l.stack)], name)
+				return fmt.Errorf("import cycle: %s", strings.Join(cycle, " → "))
+			}
+		}
+	}
+	l.loaded[name] = false
+	l.stack = append(l.stack, name)
+
+	file, err := protoParse(name, path, l.options)
+	if err != nil {
+		return err
+	}
+	l.symbols.addFile(file)
+
+	for _, location := range file.imports {
+		importPath, err := l.findImport(location)
+		if wellKnown, ok := wellKnownFiles[location]; ok && err != nil {
+			l.loadWellKnown(location, wellKnown)
+			continue
+		}
+		if err != nil {
+			return fmt.Errorf("%s: %w", name, err)
+		}
+		err = l.load(location, importPath)
+		if err != nil {
+			return err
+		}
+	}
+
+	l.stack = l.stack[:len(l.stack)-1]
+	l.loaded[name] = true
+	return nil
+}
+
+// loadWellKnown загружает встроенный файл well-known types, которого нет в include директориях
+func (l *protoLoader) loadWellKnown(location string, descriptor protoreflect.FileDescriptor) {
+	if _, seen := l.loaded[location]; seen {
+		return
+	}
+	l.loaded[location] = true
+	l.symbols.addFile(newWellKnownProtoFile(location, descriptor))
+}
+
+// findImport ищет импортируемый файл в include директориях по порядку
+func (l *protoLoader) findImport(location string) (string, error) {
+	for _, includePath := range l.includePaths {
+		path := filepath.Join(includePath, filepath.FromSlash(location))
+		if info, err := os.Stat(path); err == nil && !info.IsDir() {
+			return path, nil
+		}
+	}
+	return "", fmt.Errorf("can not find import \"%s\" in include paths %v", location, l.includePaths)
+}
+
+// canonicalName возвращает имя файла относительно include директории, в которой он лежит,
+// то есть то же имя, под которым его импортируют другие файлы.
+func (l *protoLoader) canonicalName(path string) string {
+	absPath, err := filepath.Abs(path)
+	if err != nil {
+		return filepath.ToSlash(path)
+	}
+	for _, includePath := range l.includePaths {
+		absInclude, err := filepath.Abs(includePath)
+		if err != nil {
+			continue
+		}
+		rel, err := filepath.Rel(absInclude, absPath)
+		if err == nil && rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+			return filepath.ToSlash(rel)
+		}
+	}
+	return filepath.ToSlash(path)
+}
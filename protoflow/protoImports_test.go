@@ -0,0 +1,79 @@
+package protoflow
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// writeProtoFiles записывает файлы files в dir, ключ - путь относительно dir
+func writeProtoFiles(t *testing.T, dir string, files map[string]string) {
+	t.Helper()
+
+	for name, content := range files {
+		path := filepath.Join(dir, filepath.FromSlash(name))
+		require.NoError(t, os.MkdirAll(filepath.Dir(path), 0o700))
+		require.NoError(t, os.WriteFile(path, []byte(content), 0o600))
+	}
+}
+
+func TestLoadProtoFiles_Imports(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	writeProtoFiles(t, dir, map[string]string{
+		"playbooks/playbook.proto": `syntax = "proto3";
+package example.playbook;
+import "common/incident.proto";
+import "common/user.proto";
+service Playbook { rpc Get (example.common.Incident) returns (example.common.User) {} }
+`,
+		"shared/common/incident.proto": `syntax = "proto3";
+package example.common;
+import "common/user.proto";
+message Incident { User user = 1; }
+`,
+		"shared/common/user.proto": `syntax = "proto3";
+package example.common;
+message User { string name = 1; }
+`,
+	})
+
+	includePaths := []string{filepath.Join(dir, "playbooks"), filepath.Join(dir, "shared")}
+	registry, err := LoadProtoFiles(filepath.Join(dir, "playbooks", "playbook.proto"), includePaths, ParseOptions{})
+	require.NoError(t, err)
+
+	method, err := registry.resolveRPC("example.playbook", "Playbook", "Get")
+	require.NoError(t, err)
+	assert.Equal(t, "example.common.Incident", method.Request)
+	fields, err := registry.messageFields("example.common.Incident")
+	require.NoError(t, err)
+	assert.Equal(t, []protoField{{Name: "user", JSONName: "user", Type: fieldType{Name: "example.common.User"}}}, fields)
+
+	_, err = LoadProtoFiles(filepath.Join(dir, "playbooks", "playbook.proto"), nil, ParseOptions{})
+	assert.EqualError(t, err, `playbook.proto: can not find import "common/incident.proto" in include paths [`+
+		filepath.Join(dir, "playbooks")+`]`)
+}
+
+func TestLoadProtoFiles_ImportCycle(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	writeProtoFiles(t, dir, map[string]string{
+		"a.proto": `syntax = "proto3";
+import "b.proto";
+`,
+		"b.proto": `syntax = "proto3";
+import "c.proto";
+`,
+		"c.proto": `syntax = "proto3";
+import "b.proto";
+`,
+	})
+
+	_, err := LoadProtoFiles(filepath.Join(dir, "a.proto"), nil, ParseOptions{})
+	assert.EqualError(t, err, "import cycle: b.proto → c.proto → b.proto")
+}
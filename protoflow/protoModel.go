@@ -0,0 +1,227 @@
+package protoflow
+
+import (
+	"strconv"
+	"strings"
+
+	"github.com/yoheimuta/go-protoparser/v4/parser"
+)
+
+// protoFile - разобранный proto3 файл
+type protoFile struct {
+	// name - имя файла так, как его импортируют другие файлы
+	name     string
+	pkg      string
+	imports  []string
+	options  []protoOption
+	messages []*protoMessage
+	enums    []*protoEnum
+	services []*protoService
+}
+
+// protoMessage - сообщение вместе с вложенными сообщениями и enum
+type protoMessage struct {
+	name     string
+	fields   []*protoMessageField
+	messages []*protoMessage
+	enums    []*protoEnum
+	options  []protoOption
+	comments []string
+}
+
+// protoMessageField - обычное поле, map поле или поле oneof.
+// У map поля заполнен mapKey, у поля oneof - oneof.
+type protoMessageField struct {
+	name     string
+	typeName string
+	number   string
+	repeated bool
+	mapKey   string
+	oneof    string
+	options  []protoOption
+	comments []string
+}
+
+// jsonName возвращает имя поля в JSON: значение option json_name или имя в lowerCamelCase
+func (f *protoMessageField) jsonName() string {
+	for _, option := range f.options {
+		if option.name == "json_name" {
+			return unquoteConstant(option.value)
+		}
+	}
+	return jsonFieldName(f.name)
+}
+
+// protoEnum - enum и его значения
+type protoEnum struct {
+	name     string
+	values   []protoEnumValue
+	options  []protoOption
+	comments []string
+}
+
+type protoEnumValue struct {
+	name   string
+	number string
+}
+
+// protoService - сервис и его методы
+type protoService struct {
+	name     string
+	methods  []*protoMethod
+	options  []protoOption
+	comments []string
+}
+
+// protoMethod - rpc метод. request и response - имена сообщений так, как они записаны в файле.
+type protoMethod struct {
+	name           string
+	request        string
+	response       string
+	requestStream  bool
+	responseStream bool
+	options        []protoOption
+	comments       []string
+}
+
+// protoOption - option файла, сообщения, поля или метода. value - константа так, как она записана в файле.
+type protoOption struct {
+	name  string
+	value string
+}
+
+// newProtoFile строит модель файла name из структуры go-protoparser
+func newProtoFile(name string, proto *parser.Proto) *protoFile {
+	file := &protoFile{name: name}
+	for _, visitee := range proto.ProtoBody {
+		switch element := visitee.(type) {
+		case *parser.Package:
+			file.pkg = element.Name
+		case *parser.Import:
+			file.imports = append(file.imports, unquoteConstant(element.Location))
+		case *parser.Option:
+			file.options = append(file.options, newProtoOption(element.OptionName, element.Constant))
+		case *parser.Message:
+			file.messages = append(file.messages, newProtoMessage(element))
+		case *parser.Enum:
+			file.enums = append(file.enums, newProtoEnum(element))
+		case *parser.Service:
+			file.services = append(file.services, newProtoService(element))
+		}
+	}
+	return file
+}
+
+func newProtoMessage(message *parser.Message) *protoMessage {
+	result := &protoMessage{name: message.MessageName, comments: protoComments(message.Comments)}
+	for _, visitee := range message.MessageBody {
+		switch element := visitee.(type) {
+		case *parser.Field:
+			result.fields = append(result.fields, &protoMessageField{
+				name:     element.FieldName,
+				typeName: element.Type,
+				number:   element.FieldNumber,
+				repeated: element.IsRepeated,
+				options:  fieldOptions(element.FieldOptions),
+				comments: protoComments(element.Comments),
+			})
+		case *parser.MapField:
+			result.fields = append(result.fields, &protoMessageField{
+				name:     element.MapName,
+				typeName: element.Type,
+				number:   element.FieldNumber,
+				mapKey:   element.KeyType,
+				options:  fieldOptions(element.FieldOptions),
+				comments: protoComments(element.Comments),
+			})
+		case *parser.Oneof:
+			for _, field := range element.OneofFields {
+				result.fields = append(result.fields, &protoMessageField{
+					name:     field.FieldName,
+					typeName: field.Type,
+					number:   field.FieldNumber,
+					oneof:    element.OneofName,
+					options:  fieldOptions(field.FieldOptions),
+					comments: protoComments(field.Comments),
+				})
+			}
+		case *parser.Option:
+			result.options = append(result.options, newProtoOption(element.OptionName, element.Constant))
+		case *parser.Message:
+			result.messages = append(result.messages, newProtoMessage(element))
+		case *parser.Enum:
+			result.enums = append(result.enums, newProtoEnum(element))
+		}
+	}
+	return result
+}
+
+func newProtoEnum(enum *parser.Enum) *protoEnum {
+	result := &protoEnum{name: enum.EnumName, comments: protoComments(enum.Comments)}
+	for _, visitee := range enum.EnumBody {
+		switch element := visitee.(type) {
+		case *parser.EnumField:
+			result.values = append(result.values, protoEnumValue{name: element.Ident, number: element.Number})
+		case *parser.Option:
+			result.options = append(result.options, newProtoOption(element.OptionName, element.Constant))
+		}
+	}
+	return result
+}
+
+func newProtoService(service *parser.Service) *protoService {
+	result := &protoService{name: service.ServiceName, comments: protoComments(service.Comments)}
+	for _, visitee := range service.ServiceBody {
+		switch element := visitee.(type) {
+		case *parser.RPC:
+			method := &protoMethod{
+				name:     element.RPCName,
+				comments: protoComments(element.Comments),
+			}
+			if element.RPCRequest != nil {
+				method.request = element.RPCRequest.MessageType
+				method.requestStream = element.RPCRequest.IsStream
+			}
+			if element.RPCResponse != nil {
+				method.response = element.RPCResponse.MessageType
+				method.responseStream = element.RPCResponse.IsStream
+			}
+			for _, option := range element.Options {
+				method.options = append(method.options, newProtoOption(option.OptionName, option.Constant))
+			}
+			result.methods = append(result.methods, method)
+		case *parser.Option:
+			result.options = append(result.options, newProtoOption(element.OptionName, element.Constant))
+		}
+	}
+	return result
+}
+
+func newProtoOption(name string, value string) protoOption {
+	return protoOption{name: name, value: value}
+}
+
+func fieldOptions(options []*parser.FieldOption) []protoOption {
+	var result []protoOption
+	for _, option := range options {
+		result = append(result, newProtoOption(option.OptionName, option.Constant))
+	}
+	return result
+}
+
+// protoComments возвращает текст комментариев без // и /* */
+func protoComments(comments []*parser.Comment) []string {
+	var result []string
+	for _, comment := range comments {
+		result = append(result, strings.TrimSpace(strings.Join(comment.Lines(), "\n")))
+	}
+	return result
+}
+
+// unquoteConstant убирает кавычки из строковой константы, например пути import или json_name
+func unquoteConstant(location string) string {
+	if unquoted, err := strconv.Unquote(location); err == nil {
+		return unquoted
+	}
+	return strings.Trim(location, "\"'")
+}
@@ -0,0 +1,33 @@
+package protoflow
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestProtoParse(t *testing.T) {
+	t.Parallel()
+
+	file, err := protoParse("incident.proto", "testdata/incident.proto", ParseOptions{Permissive: true})
+	require.NoError(t, err)
+
+	assert.Equal(t, "incident.proto", file.name)
+	assert.Equal(t, "example.incident", file.pkg)
+	require.Len(t, file.services, 1)
+	assert.Equal(t, "Incidents", file.services[0].name)
+	assert.Equal(t, &protoMethod{name: "Get", request: "GetIncident", response: "Incident"}, file.services[0].methods[0])
+
+	var notify *protoMessage
+	for _, message := range file.messages {
+		if message.name == "Notify" {
+			notify = message
+		}
+	}
+	require.NotNil(t, notify)
+	assert.Equal(t, []*protoMessageField{
+		{name: "user_name", typeName: "string", number: "1", oneof: "target"},
+		{name: "chat", typeName: "string", number: "2", oneof: "target"},
+	}, notify.fields)
+}
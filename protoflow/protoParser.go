@@ -0,0 +1,38 @@
+package protoflow
+
+import (
+	"fmt"
+	"os"
+
+	"path/filepath"
+
+	protoparser "github.com/yoheimuta/go-protoparser/v4"
+)
+
+// ParseOptions - настройки разбора .proto файлов go-protoparser
+type ParseOptions struct {
+	// Debug выводит подробности разбора
+	Debug bool
+	// Permissive разрешает синтаксис, который принимает protoc, но нет в спецификации
+	Permissive bool
+}
+
+// protoParse разбирает protoFileName и строит модель файла с именем name
+func protoParse(name string, protoFileName string, options ParseOptions) (*protoFile, error) {
+	reader, err := os.Open(protoFileName)
+	if err != nil {
+		return nil, fmt.Errorf("can not open proto file \"%s\": %w", protoFileName, err)
+	}
+	defer reader.Close()
+
+	got, err := protoparser.Parse(
+		reader,
+		protoparser.WithDebug(options.Debug),
+		protoparser.WithPermissive(options.Permissive),
+		protoparser.WithFilename(filepath.Base(protoFileName)),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse proto file \"%s\": %w", protoFileName, err)
+	}
+	return newProtoFile(name, got), nil
+}
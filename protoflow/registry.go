@@ -0,0 +1,137 @@
+package protoflow
+
+import (
+	"errors"
+	"sort"
+	"strings"
+	"unicode"
+)
+
+var (
+	errServiceNotFound = errors.New("service not found")
+	errMethodNotFound  = errors.New("method not found")
+)
+
+// Registry - определения proto3, по которым проверяется плейбук.
+// Создаётся LoadProtoFiles из .proto файлов, LoadDescriptorSet из скомпилированного
+// FileDescriptorSet или NewRegistry из уже загруженных дескрипторов.
+type Registry interface {
+	// hasServices сообщает, объявлен ли хотя бы один сервис
+	hasServices() bool
+	// resolveRPC ищет метод сервиса. Если pkg пустой, сервис ищется во всех package.
+	// Ошибка - errServiceNotFound, errMethodNotFound или *ambiguousSymbolError.
+	// Имена request и response сообщений разрешаются через resolveType из protoRPC.Scope.
+	resolveRPC(pkg string, service string, method string) (*protoRPC, error)
+	// resolveType ищет сообщение или enum name, на которое ссылаются из scope, по правилам protoc.
+	// Возвращает полное имя типа и признак того, что это enum.
+	// Ошибка - errSymbolNotFound или *ambiguousSymbolError.
+	resolveType(name string, scope string) (string, bool, error)
+	// findType ищет сообщение или enum, заданное пользователем, например --input_type:
+	// по полному имени или по окончанию имени в любом package. Результат - как у resolveType.
+	findType(name string) (string, bool, error)
+	// messageFields возвращает поля сообщения с полным именем fullName
+	messageFields(fullName string) ([]protoField, error)
+	// enumValues возвращает имена значений enum с полным именем fullName
+	enumValues(fullName string) ([]string, error)
+}
+
+// resolveProtoName ищет определение name, на которое ссылаются из scope, так же, как protoc:
+// имя с точкой в начале - полное, остальные ищутся сначала во вложенной области видимости,
+// затем во внешних. Так ссылки разрешаются одинаково в реестрах из .proto файлов и из дескрипторов.
+// defined сообщает, объявлено ли полное имя.
+func resolveProtoName(name string, scope string, defined func(fullName string) bool) (string, error) {
+	if strings.HasPrefix(name, ".") {
+		if fullName := strings.TrimPrefix(name, "."); defined(fullName) {
+			return fullName, nil
+		}
+		return "", errSymbolNotFound
+	}
+
+	for {
+		if candidate := joinProtoName(scope, name); defined(candidate) {
+			return candidate, nil
+		}
+		if scope == "" {
+			break
+		}
+		scope = parentProtoScope(scope)
+	}
+	return "", errSymbolNotFound
+}
+
+// findProtoName ищет определение по имени, которое задал пользователь, а не proto3 файл:
+// --input_type или сервис в Resource без package. Если name - не полное имя, ищется определение
+// с таким же окончанием имени в любом package, и несколько совпадений считаются неоднозначностью.
+// fullNames перечисляет все объявленные полные имена.
+func findProtoName(name string, defined func(fullName string) bool, fullNames func() []string) (string, error) {
+	fullName, err := resolveProtoName(name, "", defined)
+	if err == nil || strings.HasPrefix(name, ".") {
+		return fullName, err
+	}
+
+	var candidates []string
+	for _, fullName := range fullNames() {
+		if strings.HasSuffix(fullName, "."+name) {
+			candidates = append(candidates, fullName)
+		}
+	}
+	switch len(candidates) {
+	case 0:
+		return "", errSymbolNotFound
+	case 1:
+		return candidates[0], nil
+	}
+	sort.Strings(candidates)
+	return "", &ambiguousSymbolError{name: name, candidates: candidates}
+}
+
+func joinProtoName(scope string, name string) string {
+	if scope == "" {
+		return name
+	}
+	return scope + "." + name
+}
+
+func parentProtoScope(scope string) string {
+	if i := strings.LastIndex(scope, "."); i >= 0 {
+		return scope[:i]
+	}
+	return ""
+}
+
+// protoRPC - метод сервиса. Request и Response - имена сообщений так, как они
+// записаны в определении метода, Scope - область видимости, из которой они разрешаются.
+type protoRPC struct {
+	Request  string
+	Response string
+	Scope    string
+}
+
+// protoField - поле сообщения. В Type имя сообщения или enum разрешено
+// до полного, если его удалось найти. Oneof - имя oneof, в который входит поле.
+// JSONName - имя поля в JSON: json_name или имя в lowerCamelCase.
+type protoField struct {
+	Name     string
+	JSONName string
+	Type     fieldType
+	Oneof    string
+}
+
+// jsonFieldName возвращает имя поля в JSON так же, как protoc без json_name:
+// подчёркивания убираются, а следующая за ними буква становится заглавной
+func jsonFieldName(name string) string {
+	var result strings.Builder
+	upper := false
+	for _, r := range name {
+		switch {
+		case r == '_':
+			upper = true
+		case upper:
+			result.WriteRune(unicode.ToUpper(r))
+			upper = false
+		default:
+			result.WriteRune(r)
+		}
+	}
+	return result.String()
+}
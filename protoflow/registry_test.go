@@ -0,0 +1,153 @@
+package protoflow
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/descriptorpb"
+)
+
+const (
+	resolveTestProtoA = `syntax = "proto3";
+
+package example.a;
+
+import "b.proto";
+
+message Req {}
+
+message Only {}
+
+message Outer {
+    message Inner {}
+    enum Level {
+        LOW = 0;
+    }
+}
+`
+	resolveTestProtoB = `syntax = "proto3";
+
+package example.b;
+
+message Req {}
+
+enum Level {
+    LEVEL_UNSPECIFIED = 0;
+}
+`
+)
+
+// resolveTestDescriptorSet - те же определения, что в resolveTestProtoA и resolveTestProtoB
+func resolveTestDescriptorSet() *descriptorpb.FileDescriptorSet {
+	enumValue := func(name string) []*descriptorpb.EnumValueDescriptorProto {
+		return []*descriptorpb.EnumValueDescriptorProto{{Name: proto.String(name), Number: proto.Int32(0)}}
+	}
+	return &descriptorpb.FileDescriptorSet{File: []*descriptorpb.FileDescriptorProto{
+		{
+			Name:    proto.String("b.proto"),
+			Package: proto.String("example.b"),
+			Syntax:  proto.String("proto3"),
+			MessageType: []*descriptorpb.DescriptorProto{
+				{Name: proto.String("Req")},
+			},
+			EnumType: []*descriptorpb.EnumDescriptorProto{
+				{Name: proto.String("Level"), Value: enumValue("LEVEL_UNSPECIFIED")},
+			},
+		},
+		{
+			Name:       proto.String("a.proto"),
+			Package:    proto.String("example.a"),
+			Syntax:     proto.String("proto3"),
+			Dependency: []string{"b.proto"},
+			MessageType: []*descriptorpb.DescriptorProto{
+				{Name: proto.String("Req")},
+				{Name: proto.String("Only")},
+				{
+					Name:       proto.String("Outer"),
+					NestedType: []*descriptorpb.DescriptorProto{{Name: proto.String("Inner")}},
+					EnumType: []*descriptorpb.EnumDescriptorProto{
+						{Name: proto.String("Level"), Value: enumValue("LOW")},
+					},
+				},
+			},
+		},
+	}}
+}
+
+func TestRegistry_ResolveType(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "a.proto"), []byte(resolveTestProtoA), 0o600))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "b.proto"), []byte(resolveTestProtoB), 0o600))
+	protoRegistry, err := LoadProtoFiles(filepath.Join(dir, "a.proto"), nil, ParseOptions{})
+	require.NoError(t, err)
+
+	data, err := proto.Marshal(resolveTestDescriptorSet())
+	require.NoError(t, err)
+	descriptorSetFile := filepath.Join(dir, "a.pb")
+	require.NoError(t, os.WriteFile(descriptorSetFile, data, 0o600))
+	descriptorRegistry, err := LoadDescriptorSet(descriptorSetFile)
+	require.NoError(t, err)
+
+	// find - искать как findType, а не resolveType
+	testCases := []struct {
+		find       bool
+		name       string
+		scope      string
+		fullName   string
+		isEnum     bool
+		candidates []string
+		notFound   bool
+	}{
+		{name: ".example.b.Req", fullName: "example.b.Req"},
+		{name: "Req", scope: "example.a", fullName: "example.a.Req"},
+		{name: "Inner", scope: "example.a.Outer", fullName: "example.a.Outer.Inner"},
+		{name: "Outer.Inner", scope: "example.a.Req", fullName: "example.a.Outer.Inner"},
+		{name: "Level", scope: "example.a.Outer", fullName: "example.a.Outer.Level", isEnum: true},
+		{name: "Level", scope: "example.b", fullName: "example.b.Level", isEnum: true},
+		{name: "example.a.Only", fullName: "example.a.Only"},
+		// ссылки из proto3 файлов не ищутся в других package
+		{name: "Req", notFound: true},
+		{name: "Only", scope: "example.b", notFound: true},
+		{name: ".Req", notFound: true},
+		{name: "Missing", notFound: true},
+		// имена от пользователя ищутся по окончанию в любом package
+		{find: true, name: "example.b.Req", fullName: "example.b.Req"},
+		{find: true, name: "Only", fullName: "example.a.Only"},
+		{find: true, name: "Outer.Inner", fullName: "example.a.Outer.Inner"},
+		{find: true, name: "Req", candidates: []string{"example.a.Req", "example.b.Req"}},
+		{find: true, name: "Level", candidates: []string{"example.a.Outer.Level", "example.b.Level"}},
+		{find: true, name: ".Only", notFound: true},
+		{find: true, name: "Missing", notFound: true},
+	}
+
+	registries := map[string]Registry{"proto files": protoRegistry, "descriptor set": descriptorRegistry}
+	for registryName, registry := range registries {
+		for _, tc := range testCases {
+			description := registryName + ": " + tc.scope + " " + tc.name
+			fullName, isEnum, err := registry.resolveType(tc.name, tc.scope)
+			if tc.find {
+				description = registryName + ": find " + tc.name
+				fullName, isEnum, err = registry.findType(tc.name)
+			}
+
+			var ambiguous *ambiguousSymbolError
+			switch {
+			case tc.notFound:
+				assert.ErrorIs(t, err, errSymbolNotFound, description)
+			case tc.candidates != nil:
+				require.ErrorAs(t, err, &ambiguous, description)
+				assert.Equal(t, tc.candidates, ambiguous.candidates, description)
+			default:
+				require.NoError(t, err, description)
+				assert.Equal(t, tc.fullName, fullName, description)
+				assert.Equal(t, tc.isEnum, isEnum, description)
+			}
+		}
+	}
+}
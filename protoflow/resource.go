@@ -0,0 +1,103 @@
+package protoflow
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"strconv"
+	"strings"
+)
+
+// grpcResourceScheme - схема Resource задачи, которая вызывает gRPC метод
+const grpcResourceScheme = "grpc:"
+
+var errNotGrpcResource = errors.New("resource is not a grpc resource")
+
+// grpcResource - Resource задачи вида
+//
+//	grpc:<host>:<port>/<package>.<Service>/<Method>
+//
+// например grpc:127.0.0.1:5678/example.playbook.ExampleRoute/Task0.
+// Хост может быть IPv6 адресом в квадратных скобках, package может отсутствовать.
+type grpcResource struct {
+	Host    string
+	Port    int
+	Package string
+	Service string
+	Method  string
+}
+
+func parseGrpcResource(resource string) (*grpcResource, error) {
+	if !strings.HasPrefix(resource, grpcResourceScheme) {
+		return nil, errNotGrpcResource
+	}
+	rest := strings.TrimPrefix(strings.TrimPrefix(resource, grpcResourceScheme), "//")
+
+	parts := strings.Split(rest, "/")
+	if len(parts) != 3 {
+		return nil, fmt.Errorf("expected 3 parts separated by \"/\", got %d", len(parts))
+	}
+	address, fullService, method := parts[0], parts[1], parts[2]
+
+	host, portString, err := net.SplitHostPort(address)
+	if err != nil {
+		return nil, fmt.Errorf("invalid address \"%s\": %w", address, err)
+	}
+	if host == "" {
+		return nil, fmt.Errorf("empty host in address \"%s\"", address)
+	}
+	port, err := strconv.Atoi(portString)
+	if err != nil || port < 1 || port > 65535 {
+		return nil, fmt.Errorf("invalid port \"%s\"", portString)
+	}
+
+	var pkg, service string
+	if i := strings.LastIndex(fullService, "."); i >= 0 {
+		pkg, service = fullService[:i], fullService[i+1:]
+	} else {
+		service = fullService
+	}
+	if !isProtoFullIdent(pkg, true) || !isProtoFullIdent(service, false) {
+		return nil, fmt.Errorf("invalid service name \"%s\"", fullService)
+	}
+	if !isProtoFullIdent(method, false) {
+		return nil, fmt.Errorf("invalid method name \"%s\"", method)
+	}
+
+	return &grpcResource{
+		Host:    host,
+		Port:    port,
+		Package: pkg,
+		Service: service,
+		Method:  method,
+	}, nil
+}
+
+// FullService возвращает имя сервиса вместе с package, например example.playbook.ExampleRoute
+func (r *grpcResource) FullService() string {
+	if r.Package == "" {
+		return r.Service
+	}
+	return r.Package + "." + r.Service
+}
+
+// isProtoFullIdent проверяет, что name - идентификатор proto3 или несколько
+// идентификаторов через точку. Если allowDots == false, точки запрещены.
+func isProtoFullIdent(name string, allowDots bool) bool {
+	if name == "" {
+		return allowDots
+	}
+	for _, ident := range strings.Split(name, ".") {
+		if ident == "" || (!allowDots && ident != name) {
+			return false
+		}
+		for i, r := range ident {
+			isLetter := r == '_' || ('a' <= r && r <= 'z') || ('A' <= r && r <= 'Z')
+			isDigit := '0' <= r && r <= '9'
+			if !isLetter && !(isDigit && i > 0) {
+				return false
+			}
+		}
+	}
+	return true
+}
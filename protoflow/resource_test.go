@@ -0,0 +1,48 @@
+package protoflow
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseGrpcResource(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		resource string
+		expected *grpcResource
+		err      string
+	}{
+		{
+			resource: "grpc:127.0.0.1:5678/example.playbook.ExampleRoute/Task0",
+			expected: &grpcResource{Host: "127.0.0.1", Port: 5678, Package: "example.playbook",
+				Service: "ExampleRoute", Method: "Task0"},
+		},
+		{
+			resource: "grpc://[::1]:443/Incidents/Get",
+			expected: &grpcResource{Host: "::1", Port: 443, Service: "Incidents", Method: "Get"},
+		},
+		{resource: "grpc:127.0.0.1:5678:321", err: `expected 3 parts separated by "/", got 1`},
+		{resource: "grpc:127.0.0.1/Incidents/Get", err: `invalid address "127.0.0.1"`},
+		{resource: "grpc::5678/Incidents/Get", err: `empty host in address ":5678"`},
+		{resource: "grpc:localhost:70000/Incidents/Get", err: `invalid port "70000"`},
+		{resource: "grpc:localhost:5678/example..Incidents/Get", err: `invalid service name "example..Incidents"`},
+		{resource: "grpc:localhost:5678/Incidents/1Get", err: `invalid method name "1Get"`},
+	}
+
+	for _, tc := range testCases {
+		resource, err := parseGrpcResource(tc.resource)
+		if tc.err != "" {
+			require.Error(t, err, tc.resource)
+			assert.Contains(t, err.Error(), tc.err, tc.resource)
+			continue
+		}
+		require.NoError(t, err, tc.resource)
+		assert.Equal(t, tc.expected, resource, tc.resource)
+	}
+
+	_, err := parseGrpcResource("arn:aws:states:::lambda:invoke")
+	assert.ErrorIs(t, err, errNotGrpcResource)
+}
@@ -0,0 +1,125 @@
+package protoflow
+
+import "sort"
+
+// maxStateInputs - сколько разных значений может прийти на вход одного состояния.
+// Ограничивает обход циклов, в которых значение растёт на каждой итерации.
+const maxStateInputs = 32
+
+// stateNode - состояние автомата и переходы из него
+type stateNode struct {
+	name      string
+	step      map[string]interface{}
+	stateType string
+	// end - состояние завершает автомат
+	end bool
+	// next - состояние из Next. Пустое, если переход не задан или ведёт в несуществующее состояние.
+	next string
+	// choices - состояния из Choices и Default
+	choices []string
+	// catchers - обработчики Catch, которые ведут в существующие состояния
+	catchers []stateCatcher
+}
+
+// stateCatcher - обработчик Catch и его номер в списке Catch
+type stateCatcher struct {
+	index int
+	step  map[string]interface{}
+	next  string
+}
+
+// stateGraph - граф переходов автомата плейбука, ветки Parallel или Iterator Map.
+// Переходы в несуществующие состояния отбрасываются при построении графа.
+type stateGraph struct {
+	startAt string
+	nodes   map[string]*stateNode
+	// location - положение автомата в плейбуке, например [Parallel0 Branches[1]]
+	location []string
+}
+
+// newStateGraph строит граф автомата и добавляет в problems ошибки его переходов
+func newStateGraph(startAt string, states map[string]interface{}, location []string, problems *issueList) *stateGraph {
+	graph := &stateGraph{
+		startAt:  startAt,
+		nodes:    make(map[string]*stateNode, len(states)),
+		location: location,
+	}
+	names := make([]string, 0, len(states))
+	for name, value := range states {
+		step, _ := value.(map[string]interface{})
+		stateType, _ := step["Type"].(string)
+		// "End": false не завершает автомат, переход берётся из Next
+		end, _ := step["End"].(bool)
+		graph.nodes[name] = &stateNode{name: name, step: step, stateType: stateType, end: end}
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	if _, ok := graph.nodes[startAt]; !ok {
+		problems.Append(stateDoesNotExist(location, startAt))
+	}
+	for _, name := range names {
+		graph.addTransitions(graph.nodes[name], problems)
+	}
+	return graph
+}
+
+func (g *stateGraph) addTransitions(node *stateNode, problems *issueList) {
+	path := append(g.location[:len(g.location):len(g.location)], node.name)
+	exists := func(next string) bool {
+		if _, ok := g.nodes[next]; ok {
+			return true
+		}
+		problems.Append(transitionToMissingState(path, next))
+		return false
+	}
+
+	switch node.stateType {
+	case "Choice":
+		choices, _ := node.step["Choices"].([]interface{})
+		for _, choice := range choices {
+			rule, _ := choice.(map[string]interface{})
+			if next, ok := rule["Next"].(string); ok && exists(next) {
+				node.choices = append(node.choices, next)
+			}
+		}
+		if next, ok := node.step["Default"].(string); ok && exists(next) {
+			node.choices = append(node.choices, next)
+		}
+		return
+	case "Succeed", "Fail":
+		return
+	}
+
+	if !node.end {
+		next, _ := node.step["Next"].(string)
+		switch {
+		case next == "":
+			problems.Append(stateHasNoTransition(path))
+		case exists(next):
+			node.next = next
+		}
+	}
+
+	if node.stateType != "Task" && node.stateType != "Parallel" && node.stateType != "Map" {
+		return
+	}
+	catchers, _ := node.step["Catch"].([]interface{})
+	for i, value := range catchers {
+		catcher, _ := value.(map[string]interface{})
+		next, _ := catcher["Next"].(string)
+		if next == "" {
+			problems.Append(catcherHasNoTransition(path, i))
+			continue
+		}
+		if exists(next) {
+			node.catchers = append(node.catchers, stateCatcher{index: i, step: catcher, next: next})
+		}
+	}
+}
+
+// nestedLocation возвращает положение вложенного автомата состояния stateName,
+// например ветки Parallel: nestedLocation("Parallel0", "Branches[1]")
+func (g *stateGraph) nestedLocation(stateName string, machine string) []string {
+	return append(g.location[:len(g.location):len(g.location)], stateName, machine)
+}
@@ -0,0 +1,224 @@
+package protoflow
+
+import (
+	"encoding/json"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// valueKind - вид JSON значения, которое передаётся между состояниями
+type valueKind int
+
+const (
+	// objectKind - объект с известными полями, например собранный Parameters
+	objectKind valueKind = iota
+	// tupleKind - массив фиксированной длины, например выход Parallel
+	tupleKind
+	// listKind - массив элементов одного типа, например выход Map
+	listKind
+	// protoKind - значение поля proto3, например response задачи или элемент repeated поля.
+	// Сообщение раскрывается в objectKind, repeated поле - в listKind, когда к ним обращаются.
+	protoKind
+	// literalKind - строка, число, true, false или null, записанные в плейбуке
+	literalKind
+	// anyKind - значение, тип которого не известен, например поле контекста $$.Execution
+	anyKind
+)
+
+// repeatedPrefix - префикс типа repeated поля, например "repeated string"
+const repeatedPrefix = "repeated "
+
+// protoScalarTypes - скалярные типы proto3
+var protoScalarTypes = map[string]struct{}{
+	"double": {}, "float": {},
+	"int32": {}, "int64": {}, "uint32": {}, "uint64": {}, "sint32": {}, "sint64": {},
+	"fixed32": {}, "fixed64": {}, "sfixed32": {}, "sfixed64": {},
+	"bool": {}, "string": {}, "bytes": {},
+}
+
+// stateValue - модель JSON, который передаётся между состояниями плейбука.
+// Значения не изменяются после создания, преобразования возвращают новые значения.
+type stateValue struct {
+	kind valueKind
+	// fields - поля объекта по названию
+	fields map[string]*stateValue
+	// message - полное имя сообщения, если объект - это в точности раскрытое сообщение proto3
+	message string
+	// jsonNames - названия полей раскрытого сообщения proto3 по их именам в proto3 JSON,
+	// если они отличаются: json_name или имя в lowerCamelCase
+	jsonNames map[string]string
+	// items - элементы массива фиксированной длины по порядку
+	items []*stateValue
+	// elem - тип элементов массива переменной длины
+	elem *stateValue
+	// proto - тип поля proto3
+	proto fieldType
+	// literal - значение литерала так, как оно разобрано encoding/json
+	literal interface{}
+	// origin - откуда значение взялось. Не входит в String, поэтому значения одной формы
+	// с разным происхождением считаются одинаковыми при обходе графа.
+	origin Origin
+}
+
+func newObjectValue(fields map[string]*stateValue) *stateValue {
+	return &stateValue{kind: objectKind, fields: fields}
+}
+
+func newArrayValue(items []*stateValue) *stateValue {
+	return &stateValue{kind: tupleKind, items: items}
+}
+
+func newListValue(elem *stateValue) *stateValue {
+	return &stateValue{kind: listKind, elem: elem}
+}
+
+func newProtoValue(proto fieldType) *stateValue {
+	return &stateValue{kind: protoKind, proto: proto}
+}
+
+func newAnyValue() *stateValue {
+	return &stateValue{kind: anyKind}
+}
+
+// newLiteralValue возвращает значение JSON из плейбука: объекты и массивы
+// становятся objectKind и tupleKind, остальное - literalKind.
+// origin - где в плейбуке записано значение, его поля получают его же с путём до них.
+func newLiteralValue(literal interface{}, origin Origin) *stateValue {
+	switch literal := literal.(type) {
+	case map[string]interface{}:
+		fields := make(map[string]*stateValue, len(literal))
+		for key, value := range literal {
+			fields[key] = newLiteralValue(value, origin.field(key))
+		}
+		return newObjectValue(fields).withOrigin(origin)
+	case []interface{}:
+		items := make([]*stateValue, 0, len(literal))
+		for i, value := range literal {
+			items = append(items, newLiteralValue(value, origin.field(strconv.Itoa(i))))
+		}
+		return newArrayValue(items).withOrigin(origin)
+	}
+	return &stateValue{kind: literalKind, literal: literal, origin: origin}
+}
+
+// withOrigin возвращает копию значения с происхождением origin
+func (v *stateValue) withOrigin(origin Origin) *stateValue {
+	copied := *v
+	copied.origin = origin
+	return &copied
+}
+
+// withField возвращает копию объекта, в которой поле name равно value.
+// Копия уже не совпадает в точности с сообщением proto3, но сохраняет его происхождение.
+func (v *stateValue) withField(name string, value *stateValue) *stateValue {
+	fields := make(map[string]*stateValue, len(v.fields)+1)
+	for key, field := range v.fields {
+		fields[key] = field
+	}
+	fields[name] = value
+	object := newObjectValue(fields).withOrigin(v.origin)
+	object.jsonNames = v.jsonNames
+	return object
+}
+
+// fieldName возвращает название поля объекта, к которому обращаются по name:
+// само name или имя поля proto3, которое в proto3 JSON записывается как name
+func (v *stateValue) fieldName(name string) string {
+	if _, ok := v.fields[name]; ok {
+		return name
+	}
+	if fieldName, ok := v.jsonNames[name]; ok {
+		return fieldName
+	}
+	return name
+}
+
+// protoType возвращает тип proto3, которым можно описать значение целиком:
+// значение поля proto3, раскрытое сообщение или массив из них.
+func (v *stateValue) protoType() (fieldType, bool) {
+	switch v.kind {
+	case protoKind:
+		return v.proto, true
+	case objectKind:
+		return fieldType{Name: v.message}, v.message != ""
+	case listKind:
+		elem, ok := v.elem.protoType()
+		if !ok || elem.Repeated || elem.MapKey != "" {
+			return fieldType{}, false
+		}
+		elem.Repeated = true
+		return elem, true
+	}
+	return fieldType{}, false
+}
+
+// String возвращает форму значения, например "[{id uint64}, repeated example.playbook.GeoIP]"
+func (v *stateValue) String() string {
+	switch v.kind {
+	case tupleKind:
+		items := make([]string, 0, len(v.items))
+		for _, item := range v.items {
+			items = append(items, item.String())
+		}
+		return "[" + strings.Join(items, ", ") + "]"
+	case listKind:
+		return repeatedPrefix + v.elem.String()
+	case protoKind:
+		return v.proto.String()
+	case literalKind:
+		literal, _ := json.Marshal(v.literal)
+		return string(literal)
+	case anyKind:
+		return "any"
+	}
+
+	if v.message != "" {
+		return v.message
+	}
+	keys := make([]string, 0, len(v.fields))
+	for key := range v.fields {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	fields := make([]string, 0, len(keys))
+	for _, key := range keys {
+		fields = append(fields, key+" "+v.fields[key].String())
+	}
+	return "{" + strings.Join(fields, ", ") + "}"
+}
+
+// countCombinations возвращает, сколько выходов построит combineBranchOutputs,
+// но не больше maxStateInputs+1, чтобы произведение не переполнялось
+func countCombinations(branchOutputs [][]*stateValue) int {
+	count := 1
+	for _, outputs := range branchOutputs {
+		count *= len(outputs)
+		if count > maxStateInputs {
+			return maxStateInputs + 1
+		}
+	}
+	return count
+}
+
+// combineBranchOutputs строит выходы Parallel: по одному массиву на каждое сочетание
+// возможных выходов веток. Элемент массива i - выход ветки i.
+func combineBranchOutputs(branchOutputs [][]*stateValue) []*stateValue {
+	combinations := [][]*stateValue{{}}
+	for _, outputs := range branchOutputs {
+		var next [][]*stateValue
+		for _, combination := range combinations {
+			for _, output := range outputs {
+				items := append(combination[:len(combination):len(combination)], output)
+				next = append(next, items)
+			}
+		}
+		combinations = next
+	}
+
+	result := make([]*stateValue, 0, len(combinations))
+	for _, items := range combinations {
+		result = append(result, newArrayValue(items))
+	}
+	return result
+}
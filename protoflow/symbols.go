@@ -0,0 +1,223 @@
+package protoflow
+
+import (
+	"errors"
+	"strings"
+)
+
+var errSymbolNotFound = errors.New("symbol not found")
+
+// ambiguousSymbolError - ссылка подходит под несколько определений
+type ambiguousSymbolError struct {
+	name       string
+	candidates []string
+}
+
+func (e *ambiguousSymbolError) Error() string {
+	return "ambiguous reference " + e.name + ": " + strings.Join(e.candidates, ", ")
+}
+
+// protoDefinition - сообщение, enum или сервис из proto3 файла.
+// Заполнено одно из полей message, enum и service.
+type protoDefinition struct {
+	message *protoMessage
+	enum    *protoEnum
+	service *protoService
+	// pkg - package файла, в котором объявлено определение
+	pkg string
+	// file - имя файла, в котором объявлено определение
+	file string
+}
+
+// protoSymbols - таблица символов proto3 файлов. Ключ - полное имя определения
+// вместе с package и внешними сообщениями, например example.playbook.Incident.Host.
+type protoSymbols struct {
+	messages map[string][]*protoDefinition
+	enums    map[string][]*protoDefinition
+	services map[string][]*protoDefinition
+}
+
+func newProtoSymbols() *protoSymbols {
+	return &protoSymbols{
+		messages: map[string][]*protoDefinition{},
+		enums:    map[string][]*protoDefinition{},
+		services: map[string][]*protoDefinition{},
+	}
+}
+
+// addFile добавляет в таблицу все сообщения, enum и сервисы proto3 файла,
+// включая вложенные сообщения и enum.
+func (s *protoSymbols) addFile(file *protoFile) {
+	for _, service := range file.services {
+		fullName := joinProtoName(file.pkg, service.name)
+		s.services[fullName] = append(s.services[fullName], &protoDefinition{service: service, pkg: file.pkg, file: file.name})
+	}
+	s.addDefinitions(file, file.pkg, file.messages, file.enums)
+}
+
+// addDefinitions рекурсивно добавляет сообщения и enum с префиксом scope
+func (s *protoSymbols) addDefinitions(file *protoFile, scope string, messages []*protoMessage, enums []*protoEnum) {
+	for _, message := range messages {
+		fullName := joinProtoName(scope, message.name)
+		s.messages[fullName] = append(s.messages[fullName], &protoDefinition{message: message, pkg: file.pkg, file: file.name})
+		s.addDefinitions(file, fullName, message.messages, message.enums)
+	}
+	for _, enum := range enums {
+		fullName := joinProtoName(scope, enum.name)
+		s.enums[fullName] = append(s.enums[fullName], &protoDefinition{enum: enum, pkg: file.pkg, file: file.name})
+	}
+}
+
+func (s *protoSymbols) hasServices() bool {
+	return len(s.services) != 0
+}
+
+func (s *protoSymbols) resolveRPC(pkg string, service string, method string) (*protoRPC, error) {
+	var definition *protoDefinition
+	var err error
+	if pkg != "" {
+		_, definition, err = s.lookup(s.services, joinProtoName(pkg, service), service)
+	} else {
+		var fullName string
+		defined, fullNames := symbolNames(s.services)
+		fullName, err = findProtoName(service, defined, fullNames)
+		if err == nil {
+			_, definition, err = s.lookup(s.services, fullName, service)
+		}
+	}
+	if errors.Is(err, errSymbolNotFound) {
+		return nil, errServiceNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	for _, rpc := range definition.service.methods {
+		if rpc.name == method {
+			return &protoRPC{Request: rpc.request, Response: rpc.response, Scope: definition.pkg}, nil
+		}
+	}
+	return nil, errMethodNotFound
+}
+
+func (s *protoSymbols) messageFields(fullName string) ([]protoField, error) {
+	_, message, err := s.lookup(s.messages, fullName, fullName)
+	if err != nil {
+		return nil, err
+	}
+
+	fields := make([]protoField, 0, len(message.message.fields))
+	for _, field := range message.message.fields {
+		fields = append(fields, protoField{
+			Name:     field.name,
+			JSONName: field.jsonName(),
+			Type: fieldType{
+				Name:     s.resolveFieldType(field.typeName, fullName),
+				Repeated: field.repeated,
+				MapKey:   field.mapKey,
+			},
+			Oneof: field.oneof,
+		})
+	}
+	return fields, nil
+}
+
+func (s *protoSymbols) enumValues(fullName string) ([]string, error) {
+	_, enum, err := s.lookup(s.enums, fullName, fullName)
+	if err != nil {
+		return nil, err
+	}
+	values := make([]string, 0, len(enum.enum.values))
+	for _, value := range enum.enum.values {
+		values = append(values, value.name)
+	}
+	return values, nil
+}
+
+// resolveFieldType разрешает тип поля сообщения scope до полного имени.
+// Неразрешённый тип остаётся как есть, ошибка будет при обращении к нему.
+func (s *protoSymbols) resolveFieldType(typeName string, scope string) string {
+	if _, ok := protoScalarTypes[typeName]; ok {
+		return typeName
+	}
+	if resolved, _, err := s.resolveType(typeName, scope); err == nil {
+		return resolved
+	}
+	return typeName
+}
+
+// resolveType ищет сообщения и enum в одной области видимости, как protoc
+func (s *protoSymbols) resolveType(name string, scope string) (string, bool, error) {
+	defined, _ := symbolNames(s.messages, s.enums)
+	fullName, err := resolveProtoName(name, scope, defined)
+	if err != nil {
+		return "", false, err
+	}
+	return s.typeDefinition(fullName, name)
+}
+
+func (s *protoSymbols) findType(name string) (string, bool, error) {
+	defined, fullNames := symbolNames(s.messages, s.enums)
+	fullName, err := findProtoName(name, defined, fullNames)
+	if err != nil {
+		return "", false, err
+	}
+	return s.typeDefinition(fullName, name)
+}
+
+// typeDefinition возвращает полное имя сообщения или enum fullName и признак enum.
+// Если определение объявлено несколько раз, ссылка name неоднозначна.
+func (s *protoSymbols) typeDefinition(fullName string, name string) (string, bool, error) {
+	definitions, isEnum := s.messages, false
+	if _, ok := s.messages[fullName]; !ok {
+		definitions, isEnum = s.enums, true
+	}
+	if _, _, err := s.lookup(definitions, fullName, name); err != nil {
+		return "", false, err
+	}
+	return fullName, isEnum, nil
+}
+
+// symbolNames возвращает функции для resolveProtoName и findProtoName, которые ищут среди definitions
+func symbolNames(definitions ...map[string][]*protoDefinition) (func(string) bool, func() []string) {
+	defined := func(fullName string) bool {
+		for _, named := range definitions {
+			if _, ok := named[fullName]; ok {
+				return true
+			}
+		}
+		return false
+	}
+	fullNames := func() []string {
+		var names []string
+		for _, named := range definitions {
+			for fullName := range named {
+				names = append(names, fullName)
+			}
+		}
+		return names
+	}
+	return defined, fullNames
+}
+
+// lookup возвращает определение с полным именем fullName. Если оно объявлено
+// несколько раз, ссылка name неоднозначна.
+func (s *protoSymbols) lookup(
+	definitions map[string][]*protoDefinition,
+	fullName string,
+	name string,
+) (string, *protoDefinition, error) {
+	found := definitions[fullName]
+	switch len(found) {
+	case 0:
+		return "", nil, errSymbolNotFound
+	case 1:
+		return fullName, found[0], nil
+	}
+
+	candidates := make([]string, 0, len(found))
+	for _, definition := range found {
+		candidates = append(candidates, fullName+" ("+definition.file+")")
+	}
+	return "", nil, &ambiguousSymbolError{name: name, candidates: candidates}
+}
@@ -0,0 +1,170 @@
+package protoflow
+
+import (
+	"sort"
+	"strings"
+)
+
+// Analysis - проблемы плейбука вместе с отчётом о том, как используются его данные
+type Analysis struct {
+	Issues []Issue
+	// UnusedFields - поля response, которые не читает ни один request и ни одно правило Choice.
+	// Kind - OriginResponse, Field - поле response сообщения.
+	UnusedFields []Origin
+	// Overwrites - значения, которые ResultPath перезаписывает раньше, чем их кто-нибудь прочитает
+	Overwrites []Overwrite
+	// Outputs - возможные формы выхода плейбука, например "{id uint64, geo repeated example.GeoIP}"
+	Outputs []string
+}
+
+// Overwrite - значение Value, которое ResultPath JSONPath состояния State перезаписывает
+// до того, как его прочитают
+type Overwrite struct {
+	Value Origin
+	State string
+	// Path - путь по состояниям до State, на котором значение перезаписано впервые
+	Path     []string
+	JSONPath string
+}
+
+// dataUsage собирает, какие значения произведены, прочитаны и перезаписаны при обходе плейбука.
+// Происхождения сравниваются без Step, потому что одно значение приходит по разным путям.
+type dataUsage struct {
+	// produced - поля response сообщений в порядке обхода
+	produced []Origin
+	// reads - прочитанные значения
+	reads []Origin
+	// overwrites - перезаписанные значения, ещё не проверенные на чтение
+	overwrites []Overwrite
+	seen       map[string]struct{}
+}
+
+func newDataUsage() *dataUsage {
+	return &dataUsage{seen: map[string]struct{}{}}
+}
+
+// produce запоминает поля response сообщения, которое вернула задача
+func (u *dataUsage) produce(response Origin, fields []protoField) {
+	if !u.firstTime("produce", response) {
+		return
+	}
+	for _, field := range fields {
+		u.produced = append(u.produced, response.field(field.Name))
+	}
+}
+
+// read запоминает, что value и все значения внутри него прочитаны
+func (u *dataUsage) read(value *stateValue) {
+	for _, origin := range value.origins(true) {
+		if u.firstTime("read", origin) {
+			u.reads = append(u.reads, origin)
+		}
+	}
+}
+
+// overwrite запоминает, что ResultPath перезаписывает value
+func (u *dataUsage) overwrite(value *stateValue, jsonPath string, path []string) {
+	for _, origin := range value.origins(false) {
+		if !u.firstTime("overwrite "+path[len(path)-1]+" "+jsonPath, origin) {
+			continue
+		}
+		u.overwrites = append(u.overwrites, Overwrite{
+			Value:    origin,
+			State:    path[len(path)-1],
+			Path:     path,
+			JSONPath: jsonPath,
+		})
+	}
+}
+
+// firstTime сообщает, что событие event с значением origin случилось впервые
+func (u *dataUsage) firstTime(event string, origin Origin) bool {
+	origin.Step = 0
+	key := event + " " + originKey(origin)
+	if _, ok := u.seen[key]; ok {
+		return false
+	}
+	u.seen[key] = struct{}{}
+	return true
+}
+
+// isRead сообщает, прочитано ли значение origin, его часть или значение, в которое оно входит
+func (u *dataUsage) isRead(origin Origin) bool {
+	for _, read := range u.reads {
+		if read.Kind != origin.Kind || read.State != origin.State ||
+			read.Message != origin.Message || read.Attribute != origin.Attribute {
+			continue
+		}
+		if fieldContains(read.Field, origin.Field) || fieldContains(origin.Field, read.Field) {
+			return true
+		}
+	}
+	return false
+}
+
+// report возвращает неиспользованные поля и перезаписанные значения, которые так и не прочитаны
+func (u *dataUsage) report(analysis *Analysis) {
+	analysis.UnusedFields = []Origin{}
+	for _, field := range u.produced {
+		if !u.isRead(field) {
+			analysis.UnusedFields = append(analysis.UnusedFields, field)
+		}
+	}
+	analysis.Overwrites = []Overwrite{}
+	for _, overwrite := range u.overwrites {
+		if !u.isRead(overwrite.Value) {
+			analysis.Overwrites = append(analysis.Overwrites, overwrite)
+		}
+	}
+}
+
+// fieldContains сообщает, что поле field находится внутри parent или совпадает с ним
+func fieldContains(parent string, field string) bool {
+	return parent == "" || parent == field || strings.HasPrefix(field, parent+".")
+}
+
+// originKey - строка, по которой различаются происхождения
+func originKey(origin Origin) string {
+	return strings.Join([]string{string(origin.Kind), origin.State, origin.Message, origin.Attribute, origin.Field}, "|")
+}
+
+// origins возвращает известные происхождения значения. Если deep не задан,
+// происхождение значения скрывает происхождения значений внутри него.
+func (v *stateValue) origins(deep bool) []Origin {
+	var origins []Origin
+	if v.origin.Kind != "" {
+		origins = append(origins, v.origin)
+		if !deep {
+			return origins
+		}
+	}
+	switch v.kind {
+	case objectKind:
+		keys := make([]string, 0, len(v.fields))
+		for key := range v.fields {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		for _, key := range keys {
+			origins = append(origins, v.fields[key].origins(deep)...)
+		}
+	case tupleKind:
+		for _, item := range v.items {
+			origins = append(origins, item.origins(deep)...)
+		}
+	case listKind:
+		origins = append(origins, v.elem.origins(deep)...)
+	}
+	return origins
+}
+
+// originString - происхождения значения без Step. При построении отчёта входит в ключ
+// обхода графа, чтобы значения одной формы из разных задач проходили граф отдельно.
+func (v *stateValue) originString() string {
+	origins := v.origins(true)
+	keys := make([]string, 0, len(origins))
+	for _, origin := range origins {
+		keys = append(keys, originKey(origin))
+	}
+	return strings.Join(keys, ";")
+}
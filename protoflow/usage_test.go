@@ -0,0 +1,85 @@
+package protoflow
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAnalyze(t *testing.T) {
+	t.Parallel()
+
+	definition := `{
+		"Comment": "@input_type GetIncident",
+		"StartAt": "Get",
+		"States": {
+			"Get": {"Type": "Task", "Resource": "` + resourcePrefix + `Get", "ResultPath": "$.incident", "Next": "Block"},
+			"Block": {
+				"Type": "Task",
+				"Resource": "` + resourcePrefix + `Block",
+				"Parameters": {"user_name.$": "$.incident.user_name", "severity.$": "$.incident.severity"},
+				"ResultPath": "$.result",
+				"Next": "Blocked"
+			},
+			"Blocked": {
+				"Type": "Choice",
+				"Choices": [{"Variable": "$.result.blocked", "BooleanEquals": true, "Next": "Mark"}],
+				"Default": "Fail"
+			},
+			"Mark": {"Type": "Pass", "Result": "blocked", "ResultPath": "$.mark", "Next": "Remark"},
+			"Remark": {"Type": "Pass", "Result": "done", "ResultPath": "$.mark", "End": true},
+			"Fail": {"Type": "Fail"}
+		}
+	}`
+
+	analysis, err := Analyze([]byte(definition), loadTestRegistry(t), Options{})
+	require.NoError(t, err)
+
+	assert.Equal(t, []Issue{}, analysis.Issues)
+	incident := Origin{Kind: OriginResponse, State: "Get", Step: 1, Message: "example.incident.Incident"}
+	assert.Equal(t, []Origin{incident.field("id"), incident.field("ip")}, analysis.UnusedFields)
+	assert.Equal(t, []Overwrite{
+		{
+			Value:    Origin{Kind: OriginPlaybook, State: "Mark", Step: 4, Attribute: "Result"},
+			State:    "Remark",
+			Path:     []string{"Get", "Block", "Blocked", "Mark", "Remark"},
+			JSONPath: "$.mark",
+		},
+	}, analysis.Overwrites)
+	assert.Equal(t, []string{
+		"{id uint64, incident example.incident.Incident, mark \"done\", result example.incident.BlockResult}",
+	}, analysis.Outputs)
+}
+
+func TestAnalyze_SameIssuesAsCheck(t *testing.T) {
+	t.Parallel()
+
+	// больше maxStateInputs задач отдают в Join значения одной формы с разным происхождением
+	choices := make([]string, 0, maxStateInputs+1)
+	states := make([]string, 0, maxStateInputs+1)
+	for i := 0; i <= maxStateInputs; i++ {
+		name := fmt.Sprintf("Get%d", i)
+		choices = append(choices, `{"Variable": "$.id", "NumericEquals": `+strconv.Itoa(i)+`, "Next": "`+name+`"}`)
+		states = append(states, `"`+name+`": {"Type": "Task", "Resource": "`+resourcePrefix+`Get", "Next": "Join"}`)
+	}
+	definition := `{
+		"Comment": "@input_type example.incident.GetIncident",
+		"StartAt": "Choose",
+		"States": {
+			"Choose": {"Type": "Choice", "Choices": [` + strings.Join(choices, ", ") + `], "Default": "Join"},
+			` + strings.Join(states, ",\n") + `,
+			"Join": {"Type": "Pass", "End": true}
+		}
+	}`
+
+	registry := loadTestRegistry(t)
+	issues, err := Check([]byte(definition), registry, Options{})
+	require.NoError(t, err)
+	analysis, err := Analyze([]byte(definition), registry, Options{})
+	require.NoError(t, err)
+	assert.Equal(t, issues, analysis.Issues)
+}
@@ -0,0 +1,159 @@
+package protoflow
+
+import (
+	"strconv"
+	"strings"
+	"time"
+
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/types/known/anypb"
+	"google.golang.org/protobuf/types/known/durationpb"
+	"google.golang.org/protobuf/types/known/emptypb"
+	"google.golang.org/protobuf/types/known/fieldmaskpb"
+	"google.golang.org/protobuf/types/known/structpb"
+	"google.golang.org/protobuf/types/known/timestamppb"
+	"google.golang.org/protobuf/types/known/wrapperspb"
+)
+
+// wellKnownFiles - встроенные файлы well-known types по пути, по которому их импортируют.
+// Используются, если файла нет в include директориях или в FileDescriptorSet.
+var wellKnownFiles = map[string]protoreflect.FileDescriptor{
+	"google/protobuf/any.proto":        anypb.File_google_protobuf_any_proto,
+	"google/protobuf/duration.proto":   durationpb.File_google_protobuf_duration_proto,
+	"google/protobuf/empty.proto":      emptypb.File_google_protobuf_empty_proto,
+	"google/protobuf/field_mask.proto": fieldmaskpb.File_google_protobuf_field_mask_proto,
+	"google/protobuf/struct.proto":     structpb.File_google_protobuf_struct_proto,
+	"google/protobuf/timestamp.proto":  timestamppb.File_google_protobuf_timestamp_proto,
+	"google/protobuf/wrappers.proto":   wrapperspb.File_google_protobuf_wrappers_proto,
+}
+
+// wrapperScalars - скаляр, которым записывается в JSON обёртка google.protobuf.*Value
+var wrapperScalars = map[string]string{
+	"google.protobuf.DoubleValue": "double",
+	"google.protobuf.FloatValue":  "float",
+	"google.protobuf.Int64Value":  "int64",
+	"google.protobuf.UInt64Value": "uint64",
+	"google.protobuf.Int32Value":  "int32",
+	"google.protobuf.UInt32Value": "uint32",
+	"google.protobuf.BoolValue":   "bool",
+	"google.protobuf.StringValue": "string",
+	"google.protobuf.BytesValue":  "bytes",
+}
+
+// wellKnown* - well-known types, у которых в JSON особая форма, а не объект с их полями
+const (
+	wellKnownTimestamp = "google.protobuf.Timestamp"
+	wellKnownDuration  = "google.protobuf.Duration"
+	wellKnownFieldMask = "google.protobuf.FieldMask"
+	wellKnownStruct    = "google.protobuf.Struct"
+	wellKnownValue     = "google.protobuf.Value"
+	wellKnownListValue = "google.protobuf.ListValue"
+	wellKnownAny       = "google.protobuf.Any"
+)
+
+// hasSpecialJSON сообщает, что сообщение записывается в JSON не объектом со своими полями:
+// строкой, скаляром, произвольным объектом или массивом
+func hasSpecialJSON(message string) bool {
+	switch message {
+	case wellKnownTimestamp, wellKnownDuration, wellKnownFieldMask,
+		wellKnownStruct, wellKnownValue, wellKnownListValue, wellKnownAny:
+		return true
+	}
+	_, ok := wrapperScalars[message]
+	return ok
+}
+
+// jsonScalar возвращает скаляр, которым обёртка записывается в JSON, остальные типы - как есть
+func jsonScalar(name string) string {
+	if scalar, ok := wrapperScalars[name]; ok {
+		return scalar
+	}
+	return name
+}
+
+// compatibleWellKnownLiteral сообщает, можно ли передать литерал в поле well-known type
+// с особой формой в JSON. Обёртки проверяются как их скаляры.
+func compatibleWellKnownLiteral(literal interface{}, expected string) bool {
+	text, isString := literal.(string)
+	switch expected {
+	case wellKnownValue:
+		return true
+	case wellKnownTimestamp:
+		_, err := time.Parse(time.RFC3339Nano, text)
+		return isString && err == nil
+	case wellKnownDuration:
+		if !isString || !strings.HasSuffix(text, "s") {
+			return false
+		}
+		_, err := strconv.ParseFloat(strings.TrimSuffix(text, "s"), 64)
+		return err == nil
+	case wellKnownFieldMask:
+		return isString
+	}
+	return false
+}
+
+// newWellKnownProtoFile строит модель встроенного файла из его дескриптора
+func newWellKnownProtoFile(name string, descriptor protoreflect.FileDescriptor) *protoFile {
+	file := &protoFile{name: name, pkg: string(descriptor.Package())}
+	for i := 0; i < descriptor.Messages().Len(); i++ {
+		file.messages = append(file.messages, newDescriptorProtoMessage(descriptor.Messages().Get(i)))
+	}
+	for i := 0; i < descriptor.Enums().Len(); i++ {
+		file.enums = append(file.enums, newDescriptorProtoEnum(descriptor.Enums().Get(i)))
+	}
+	return file
+}
+
+func newDescriptorProtoMessage(descriptor protoreflect.MessageDescriptor) *protoMessage {
+	message := &protoMessage{name: string(descriptor.Name())}
+	for i := 0; i < descriptor.Fields().Len(); i++ {
+		field := descriptor.Fields().Get(i)
+		result := &protoMessageField{
+			name:     string(field.Name()),
+			typeName: modelTypeName(field),
+			number:   strconv.Itoa(int(field.Number())),
+			repeated: field.Cardinality() == protoreflect.Repeated,
+		}
+		if field.IsMap() {
+			result.typeName = modelTypeName(field.MapValue())
+			result.mapKey = descriptorTypeName(field.MapKey())
+			result.repeated = false
+		}
+		if oneof := field.ContainingOneof(); oneof != nil && !oneof.IsSynthetic() {
+			result.oneof = string(oneof.Name())
+		}
+		message.fields = append(message.fields, result)
+	}
+	for i := 0; i < descriptor.Messages().Len(); i++ {
+		if nested := descriptor.Messages().Get(i); !nested.IsMapEntry() {
+			message.messages = append(message.messages, newDescriptorProtoMessage(nested))
+		}
+	}
+	for i := 0; i < descriptor.Enums().Len(); i++ {
+		message.enums = append(message.enums, newDescriptorProtoEnum(descriptor.Enums().Get(i)))
+	}
+	return message
+}
+
+// modelTypeName возвращает тип поля так, как его записали бы в .proto файле:
+// скаляр или полное имя сообщения или enum с точкой в начале
+func modelTypeName(field protoreflect.FieldDescriptor) string {
+	switch field.Kind() {
+	case protoreflect.MessageKind, protoreflect.GroupKind, protoreflect.EnumKind:
+		return "." + descriptorTypeName(field)
+	}
+	return descriptorTypeName(field)
+}
+
+func newDescriptorProtoEnum(descriptor protoreflect.EnumDescriptor) *protoEnum {
+	enum := &protoEnum{name: string(descriptor.Name())}
+	for i := 0; i < descriptor.Values().Len(); i++ {
+		value := descriptor.Values().Get(i)
+		enum.values = append(enum.values, protoEnumValue{
+			name:   string(value.Name()),
+			number: strconv.Itoa(int(value.Number())),
+		})
+	}
+	return enum
+}
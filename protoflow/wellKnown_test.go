@@ -0,0 +1,216 @@
+package protoflow
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/descriptorpb"
+)
+
+const eventsPrefix = "grpc:127.0.0.1:5678/example.events.Events/"
+
+func TestCheck_WellKnownTypes(t *testing.T) {
+	t.Parallel()
+
+	registry, err := LoadProtoFiles("testdata/events.proto", nil, ParseOptions{Permissive: true})
+	require.NoError(t, err)
+
+	publish := func(parameters string) string {
+		return `{
+			"StartAt": "Publish",
+			"States": {
+				"Publish": {"Type": "Task", "Resource": "` + eventsPrefix + `Publish",
+					"Parameters": ` + parameters + `, "End": true}
+			}
+		}`
+	}
+	mismatch := func(field string, expected string, actual string) Issue {
+		return Issue{Code: FieldTypeMismatch, State: "Publish", Path: []string{"Publish"},
+			MessageType: "example.events.Event", Field: field, Expected: expected, Actual: actual,
+			Origin: Origin{Kind: OriginPlaybook, State: "Publish", Step: 1, Attribute: "Parameters",
+				Field: jsonFieldName(field)}}
+	}
+
+	testCases := []struct {
+		name       string
+		definition string
+		options    Options
+		expected   []Issue
+	}{
+		{
+			name: "literals in proto3 JSON form",
+			definition: publish(`{
+				"eventId": "9007199254740993",
+				"createdAt": "2021-10-01T10:00:00.5+03:00",
+				"level": "LEVEL_HIGH",
+				"retries": 3,
+				"labels": {"team": "soc"},
+				"sourceAddress": "10.0.0.1",
+				"payload": "aGVsbG8=",
+				"timeout": "1.5s"
+			}`),
+			expected: []Issue{},
+		},
+		{
+			name: "literals that proto3 JSON rejects",
+			definition: publish(`{
+				"eventId": "12a",
+				"createdAt": "yesterday",
+				"level": "HIGH",
+				"retries": 1.5,
+				"labels": "soc",
+				"sourceAddress": 10,
+				"payload": "not base64!",
+				"timeout": "10m"
+			}`),
+			expected: []Issue{
+				mismatch("event_id", "int64", `"12a"`),
+				mismatch("created_at", "google.protobuf.Timestamp", `"yesterday"`),
+				mismatch("level", "example.events.Level", `"HIGH"`),
+				mismatch("retries", "google.protobuf.Int32Value", "1.5"),
+				mismatch("labels", "google.protobuf.Struct", `"soc"`),
+				{Code: FieldTypeMismatch, State: "Publish", Path: []string{"Publish"},
+					MessageType: "example.events.Event", Field: "source_ip", Expected: "string", Actual: "10",
+					Origin: Origin{Kind: OriginPlaybook, State: "Publish", Step: 1, Attribute: "Parameters",
+						Field: "sourceAddress"}},
+				mismatch("payload", "bytes", `"not base64!"`),
+				mismatch("timeout", "google.protobuf.Duration", `"10m"`),
+			},
+		},
+		{
+			name: "response values keep their proto3 JSON form",
+			definition: `{
+				"StartAt": "Get",
+				"States": {
+					"Get": {"Type": "Task", "Resource": "` + eventsPrefix + `Get", "Next": "Record"},
+					"Record": {
+						"Type": "Task",
+						"Resource": "` + eventsPrefix + `Record",
+						"Parameters": {
+							"event_id.$": "$.event_id",
+							"level.$": "$.level",
+							"retries.$": "$.retries",
+							"details.$": "$.labels",
+							"created_at.$": "$.created_at"
+						},
+						"End": true
+					}
+				}
+			}`,
+			options:  Options{InputType: "GetEvent"},
+			expected: []Issue{},
+		},
+		{
+			name: "timestamp is a string in JSON",
+			definition: `{
+				"StartAt": "Get",
+				"States": {
+					"Get": {"Type": "Task", "Resource": "` + eventsPrefix + `Get",
+						"ResultSelector": {"seconds.$": "$.created_at.seconds"}, "End": true}
+				}
+			}`,
+			options: Options{InputType: "GetEvent"},
+			expected: []Issue{
+				{Code: PathNotFound, State: "Get", Path: []string{"Get"}, Attribute: "ResultSelector",
+					JSONPath: "$.created_at.seconds", Field: "$.created_at.seconds", Actual: "google.protobuf.Timestamp",
+					Origin: Origin{Kind: OriginResponse, State: "Get", Step: 1, Message: "example.events.Event",
+						Field: "created_at"}},
+			},
+		},
+		{
+			name: "strict mode does not convert int64 to string",
+			definition: `{
+				"StartAt": "Get",
+				"States": {
+					"Get": {"Type": "Task", "Resource": "` + eventsPrefix + `Get", "Next": "Record"},
+					"Record": {
+						"Type": "Task",
+						"Resource": "` + eventsPrefix + `Record",
+						"Parameters": {
+							"event_id.$": "$.event_id",
+							"level": "LEVEL_HIGH",
+							"retries": 1,
+							"details": null,
+							"created_at.$": "$.created_at"
+						},
+						"End": true
+					}
+				}
+			}`,
+			options: Options{InputType: "GetEvent", Compatibility: Strict},
+			expected: []Issue{
+				{Code: FieldTypeMismatch, State: "Record", Path: []string{"Get", "Record"},
+					MessageType: "example.events.Audit", Field: "event_id", Expected: "string", Actual: "int64",
+					Origin: Origin{Kind: OriginResponse, State: "Get", Step: 1, Message: "example.events.Event",
+						Field: "event_id"}},
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			issues, err := Check([]byte(tc.definition), registry, tc.options)
+			require.NoError(t, err)
+			assert.Equal(t, tc.expected, issues)
+		})
+	}
+}
+
+func TestJSONFieldName(t *testing.T) {
+	t.Parallel()
+
+	assert.Equal(t, "sourceIp", jsonFieldName("source_ip"))
+	assert.Equal(t, "eventId2", jsonFieldName("event_id2"))
+	assert.Equal(t, "name", jsonFieldName("name"))
+}
+
+func TestLoadDescriptorSet_WellKnownImports(t *testing.T) {
+	t.Parallel()
+
+	descriptorSet := &descriptorpb.FileDescriptorSet{File: []*descriptorpb.FileDescriptorProto{{
+		Name:       proto.String("alerts.proto"),
+		Package:    proto.String("example.alerts"),
+		Syntax:     proto.String("proto3"),
+		Dependency: []string{"google/protobuf/timestamp.proto"},
+		MessageType: []*descriptorpb.DescriptorProto{{
+			Name: proto.String("Alert"),
+			Field: []*descriptorpb.FieldDescriptorProto{{
+				Name:     proto.String("raised_at"),
+				JsonName: proto.String("raisedAt"),
+				Number:   proto.Int32(1),
+				Label:    descriptorpb.FieldDescriptorProto_LABEL_OPTIONAL.Enum(),
+				Type:     descriptorpb.FieldDescriptorProto_TYPE_MESSAGE.Enum(),
+				TypeName: proto.String(".google.protobuf.Timestamp"),
+			}},
+		}},
+		Service: []*descriptorpb.ServiceDescriptorProto{{
+			Name: proto.String("Alerts"),
+			Method: []*descriptorpb.MethodDescriptorProto{{
+				Name:       proto.String("Raise"),
+				InputType:  proto.String(".example.alerts.Alert"),
+				OutputType: proto.String(".example.alerts.Alert"),
+			}},
+		}},
+	}}}
+	data, err := proto.Marshal(descriptorSet)
+	require.NoError(t, err)
+	fileName := filepath.Join(t.TempDir(), "alerts.pb")
+	require.NoError(t, os.WriteFile(fileName, data, 0o600))
+
+	registry, err := LoadDescriptorSet(fileName)
+	require.NoError(t, err)
+	fields, err := registry.messageFields("example.alerts.Alert")
+	require.NoError(t, err)
+	assert.Equal(t, []protoField{{
+		Name:     "raised_at",
+		JSONName: "raisedAt",
+		Type:     fieldType{Name: "google.protobuf.Timestamp"},
+	}}, fields)
+}
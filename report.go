@@ -0,0 +1,28 @@
+package main
+
+import (
+	"github.com/pterm/pterm"
+
+	"aws-linter/protoflow"
+)
+
+// printReport выводит неиспользованные поля response, значения, перезаписанные до чтения,
+// и возможные выходы плейбука
+func printReport(analysis *protoflow.Analysis) {
+	pterm.Println()
+	pterm.Info.Println(localize("ReportUnusedFields", len(analysis.UnusedFields)))
+	for _, field := range analysis.UnusedFields {
+		pterm.Println("  - " + originText(field))
+	}
+
+	pterm.Info.Println(localize("ReportOverwrites", len(analysis.Overwrites)))
+	for _, overwrite := range analysis.Overwrites {
+		pterm.Println("  - " + localize("ReportOverwrite", originText(overwrite.Value), overwrite.JSONPath,
+			overwrite.State, formatPath(overwrite.Path)))
+	}
+
+	pterm.Info.Println(localize("ReportOutputs", len(analysis.Outputs)))
+	for _, output := range analysis.Outputs {
+		pterm.Println("  - " + output)
+	}
+}
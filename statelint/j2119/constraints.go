@@ -4,7 +4,6 @@ import (
 	"fmt"
 	"reflect"
 	"regexp"
-	"statelint/localization"
 )
 
 type Constrainter interface {
@@ -58,8 +57,7 @@ func (o *OnlyOneConstraint) Check(node Node, path string, problems *Problems) {
 	}
 
 	if len(join) > 1 {
-		problemStr := localization.GetLocalizerOrPanic().GetString("OnlyOneConstraint")
-		problems.Append(fmt.Sprintf(problemStr, path, o.fields))
+		problems.Report("OnlyOneConstraint", path, path, o.fields)
 	}
 }
 
@@ -88,8 +86,7 @@ func (n *NonEmptyConstraint) Check(node Node, path string, problems *Problems) {
 	if node.HasNode(n.name) &&
 		node.GetNode(n.name).Is(Array) &&
 		len(node.GetNode(n.name).ValueToArray()) == 0 {
-		problemStr := localization.GetLocalizerOrPanic().GetString("NonEmptyConstraint")
-		problems.Append(fmt.Sprintf(problemStr, path, n.name))
+		problems.Report("NonEmptyConstraint", path+"."+n.name, path, n.name)
 	}
 }
 
@@ -139,11 +136,15 @@ func (h *HasFieldConstraint) Check(node Node, path string, problems *Problems) {
 
 	if len(join) == 0 {
 		if len(h.names) == 1 {
-			problemStr := localization.GetLocalizerOrPanic().GetString("HasFieldConstraintSingle")
-			problems.Append(fmt.Sprintf(problemStr, path, h.names[0]))
+			problems.ReportKey(
+				"HasFieldConstraint",
+				path,
+				"HasFieldConstraintSingle",
+				path,
+				h.names[0],
+			)
 		} else {
-			problemStr := localization.GetLocalizerOrPanic().GetString("HasFieldConstraintMultiple")
-			problems.Append(fmt.Sprintf(problemStr, path, h.names))
+			problems.ReportKey("HasFieldConstraint", path, "HasFieldConstraintMultiple", path, h.names)
 		}
 	}
 }
@@ -171,8 +172,12 @@ func (d *DoesNotHaveFieldConstraint) String() string {
 
 func (d *DoesNotHaveFieldConstraint) Check(node Node, path string, problems *Problems) {
 	if node.HasNode(d.name) {
-		problemStr := localization.GetLocalizerOrPanic().GetString("DoesNotHaveFieldConstraint")
-		problems.Append(fmt.Sprintf(problemStr, path, d.name))
+		problems.Report(
+			"DoesNotHaveFieldConstraint",
+			path+"."+d.name,
+			path,
+			d.name,
+		)
 	}
 }
 
@@ -204,8 +209,7 @@ func (f *FieldTypeConstraint) Check(node Node, path string, problems *Problems)
 
 	if valueNode.IsNull() {
 		if !f.isNullable {
-			problemStr := localization.GetLocalizerOrPanic().GetString("FieldTypeConstraint")
-			problems.Append(fmt.Sprintf(problemStr, path))
+			problems.Report("FieldTypeConstraint", path, path)
 		}
 
 		return
@@ -285,8 +289,14 @@ func (f *FieldTypeConstraint) ValueCheck(value Node, path string, problems *Prob
 }
 
 func (f *FieldTypeConstraint) ReportValue(path string, value Node, message ValueType, problems *Problems) {
-	problemStr := localization.GetLocalizerOrPanic().GetString("FieldTypeConstraintReport")
-	problems.Append(fmt.Sprintf(problemStr, path, value.Types(), message))
+	problems.ReportKey(
+		"FieldTypeConstraint",
+		path,
+		"FieldTypeConstraintReport",
+		path,
+		value.Types(),
+		message,
+	)
 }
 
 type FieldValueParams struct {
@@ -348,8 +358,15 @@ func (f *FieldValueConstraint) Check(node Node, path string, problems *Problems)
 		}
 
 		if !include {
-			problemStr := localization.GetLocalizerOrPanic().GetString("FieldValueConstraintEnum")
-			problems.Append(fmt.Sprintf(problemStr, path, f.name, value.value, f.params.Enum))
+			problems.ReportKey(
+				"FieldValueConstraint",
+				path+"."+f.name,
+				"FieldValueConstraintEnum",
+				path,
+				f.name,
+				value.value,
+				f.params.Enum,
+			)
 		}
 		// if enum constraint are provided, others are ignored
 		return
@@ -358,40 +375,75 @@ func (f *FieldValueConstraint) Check(node Node, path string, problems *Problems)
 	if f.params.IsEqual {
 		// if not a number, should be caught by type constraint
 		if value.Is(Numeric) && value.ToFloat() != f.params.Equal {
-			problemStr := localization.GetLocalizerOrPanic().GetString("FieldValueConstraintEqual")
-			problems.Append(fmt.Sprintf(problemStr, path, f.name, value.value, f.params.Equal))
+			problems.ReportKey(
+				"FieldValueConstraint",
+				path+"."+f.name,
+				"FieldValueConstraintEqual",
+				path,
+				f.name,
+				value.value,
+				f.params.Equal,
+			)
 		}
 	}
 
 	if f.params.IsFloor {
 		// if not a number, should be caught by type constraint
 		if value.Is(Numeric) && value.ToFloat() <= f.params.Floor {
-			problemStr := localization.GetLocalizerOrPanic().GetString("FieldValueConstraintFloor")
-			problems.Append(fmt.Sprintf(problemStr, path, f.name, value.value, f.params.Floor))
+			problems.ReportKey(
+				"FieldValueConstraint",
+				path+"."+f.name,
+				"FieldValueConstraintFloor",
+				path,
+				f.name,
+				value.value,
+				f.params.Floor,
+			)
 		}
 	}
 
 	if f.params.IsMin {
 		// if not a number, should be caught by type constraint
 		if value.Is(Numeric) && value.ToFloat() < f.params.Min {
-			problemStr := localization.GetLocalizerOrPanic().GetString("FieldValueConstraintMin")
-			problems.Append(fmt.Sprintf(problemStr, path, f.name, value.value, f.params.Min))
+			problems.ReportKey(
+				"FieldValueConstraint",
+				path+"."+f.name,
+				"FieldValueConstraintMin",
+				path,
+				f.name,
+				value.value,
+				f.params.Min,
+			)
 		}
 	}
 
 	if f.params.IsCeiling {
 		// if not a number, should be caught by type constraint
 		if value.Is(Numeric) && value.ToFloat() >= f.params.Ceiling {
-			problemStr := localization.GetLocalizerOrPanic().GetString("FieldValueConstraintCeiling")
-			problems.Append(fmt.Sprintf(problemStr, path, f.name, value.value, f.params.Ceiling))
+			problems.ReportKey(
+				"FieldValueConstraint",
+				path+"."+f.name,
+				"FieldValueConstraintCeiling",
+				path,
+				f.name,
+				value.value,
+				f.params.Ceiling,
+			)
 		}
 	}
 
 	if f.params.IsMax {
 		// if not a number, should be caught by type constraint
 		if value.Is(Numeric) && value.ToFloat() > f.params.Max {
-			problemStr := localization.GetLocalizerOrPanic().GetString("FieldValueConstraintMax")
-			problems.Append(fmt.Sprintf(problemStr, path, f.name, value.value, f.params.Max))
+			problems.ReportKey(
+				"FieldValueConstraint",
+				path+"."+f.name,
+				"FieldValueConstraintMax",
+				path,
+				f.name,
+				value.value,
+				f.params.Max,
+			)
 		}
 	}
 }
@@ -2,6 +2,7 @@ package j2119
 
 import (
 	"math"
+	"statelint/jsonsource"
 	"time"
 )
 
@@ -40,6 +41,8 @@ type ValueType string
 type Node struct {
 	value      interface{}
 	valueTypes map[ValueType]struct{}
+	// source is nil when the node is built from a plain value without positions
+	source *jsonsource.Value
 }
 
 func NewNode(value interface{}) *Node {
@@ -49,6 +52,94 @@ func NewNode(value interface{}) *Node {
 	return n
 }
 
+func NewNodeFromSource(source *jsonsource.Value) *Node {
+	n := NewNode(source.Data)
+	n.source = source
+
+	return n
+}
+
+// Position returns where the value starts in the source
+func (n *Node) Position() (jsonsource.Position, bool) {
+	if n.source == nil {
+		return jsonsource.Position{}, false
+	}
+
+	return n.source.Position, true
+}
+
+// KeyPosition returns where the key name of the object starts in the source
+func (n *Node) KeyPosition(name string) (jsonsource.Position, bool) {
+	if n.source == nil {
+		return jsonsource.Position{}, false
+	}
+
+	position, ok := n.source.KeyPositions[name]
+
+	return position, ok
+}
+
+// Locate returns the position of the key or the array item the path leads to.
+// If the path leads nowhere, it returns the position of the deepest node found on the way.
+func (n *Node) Locate(path []PathSegment) (jsonsource.Position, bool) {
+	position, ok := n.Position()
+	current := n
+
+	for i := 0; i < len(path); i++ {
+		segment := path[i]
+
+		if segment.IsIndex {
+			if !current.Is(Array) {
+				break
+			}
+
+			items := current.ValueToArray()
+			if segment.Index >= len(items) {
+				break
+			}
+
+			current = &items[segment.Index]
+			position, ok = current.Position()
+
+			continue
+		}
+
+		// a field name may contain dots, so try to join it with the following segments
+		name, consumed := segment.Field, 0
+
+		for j := i + 1; !current.HasNode(name) && j < len(path) && !path[j].IsIndex; j++ {
+			name += "." + path[j].Field
+			consumed++
+		}
+
+		if !current.HasNode(name) {
+			break
+		}
+
+		position, ok = current.KeyPosition(name)
+		current = current.GetNode(name)
+		i += consumed
+	}
+
+	return position, ok
+}
+
+func (n *Node) field(name string, value interface{}) *Node {
+	if n.source == nil || n.source.Fields[name] == nil {
+		return NewNode(value)
+	}
+
+	return NewNodeFromSource(n.source.Fields[name])
+}
+
+func (n *Node) item(i int, value interface{}) *Node {
+	if n.source == nil || i >= len(n.source.Items) {
+		return NewNode(value)
+	}
+
+	return NewNodeFromSource(n.source.Items[i])
+}
+
 func (n *Node) Types() []ValueType {
 	result := make([]ValueType, 0, len(n.valueTypes))
 	for t := range n.valueTypes {
@@ -107,7 +198,7 @@ func (n *Node) ToObject() map[string]Node {
 
 	resultObject := make(map[string]Node)
 	for key, value := range obj {
-		resultObject[key] = *NewNode(value)
+		resultObject[key] = *n.field(key, value)
 	}
 
 	return resultObject
@@ -142,7 +233,7 @@ func (n *Node) GetNode(name string) *Node {
 	// Check is not necessary, because it's already checked when creating node
 	obj, _ := n.value.(map[string]interface{})
 	if value, ok := obj[name]; ok {
-		return NewNode(value)
+		return n.field(name, value)
 	}
 
 	panic("current node not containing node with name " + name)
@@ -185,8 +276,8 @@ func (n *Node) ValueToArray() []Node {
 	arr, _ := n.value.([]interface{})
 	result := make([]Node, 0, len(arr))
 
-	for _, item := range arr {
-		result = append(result, *NewNode(item))
+	for i, item := range arr {
+		result = append(result, *n.item(i, item))
 	}
 
 	return result
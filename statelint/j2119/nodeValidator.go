@@ -1,9 +1,6 @@
 package j2119
 
-import (
-	"fmt"
-	"statelint/localization"
-)
+import "fmt"
 
 type NodeValidator struct {
 	parser Parser
@@ -46,8 +43,12 @@ func (n *NodeValidator) Validate(initialNode Node, initialPath string, initialRo
 
 		for name, val := range currentNode.node.ToObject() {
 			if !n.parser.IsFieldAllowed(currentNode.roles, name) {
-				problemStr := localization.GetLocalizerOrPanic().GetString("NodeValidatorIsFieldAllowed")
-				problems.Append(fmt.Sprintf(problemStr, name, currentNode.path))
+				problems.Report(
+					"NodeValidatorIsFieldAllowed",
+					fmt.Sprintf("%s.%s", currentNode.path, name),
+					name,
+					currentNode.path,
+				)
 			}
 
 			// only recurse into children if they have roles
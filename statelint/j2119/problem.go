@@ -1,17 +1,195 @@
 package j2119
 
+import (
+	"fmt"
+	"regexp"
+	"statelint/jsonsource"
+	"statelint/localization"
+	"strconv"
+	"strings"
+)
+
+type Severity string
+
+const (
+	SeverityError   Severity = "error"
+	SeverityWarning Severity = "warning"
+)
+
+var severityRanks = map[Severity]int{SeverityWarning: 1, SeverityError: 2}
+
+// AtLeast reports whether s is as severe as threshold or more
+func (s Severity) AtLeast(threshold Severity) bool {
+	return severityRanks[s] >= severityRanks[threshold]
+}
+
+// ToolErrorRuleID marks problems that come from reading the input or the configuration,
+// not from validating the state machine
+const ToolErrorRuleID = "ToolError"
+
+// PathSegment is one step of a JSON path: a field name or an array index
+type PathSegment struct {
+	Field   string
+	Index   int
+	IsIndex bool
+}
+
+func (s PathSegment) String() string {
+	if s.IsIndex {
+		return fmt.Sprintf("[%d]", s.Index)
+	}
+
+	return s.Field
+}
+
+var pathIndexRegex = regexp.MustCompile(`\[(\d+)\]$`)
+
+// ParsePath splits a dotted path like "State Machine.States.x.Retry[0]" into segments
+func ParsePath(path string) []PathSegment {
+	segments := make([]PathSegment, 0)
+	if path == "" {
+		return segments
+	}
+
+	for _, part := range strings.Split(path, ".") {
+		var indexes []PathSegment
+
+		for {
+			match := pathIndexRegex.FindStringSubmatchIndex(part)
+			if match == nil {
+				break
+			}
+
+			index, _ := strconv.Atoi(part[match[2]:match[3]])
+			indexes = append([]PathSegment{{Index: index, IsIndex: true}}, indexes...)
+			part = part[:match[0]]
+		}
+
+		segments = append(segments, PathSegment{Field: part})
+		segments = append(segments, indexes...)
+	}
+
+	return segments
+}
+
+// FormatPath joins segments back into a dotted path
+func FormatPath(segments []PathSegment) string {
+	var builder strings.Builder
+
+	for i, segment := range segments {
+		if i != 0 && !segment.IsIndex {
+			builder.WriteString(".")
+		}
+
+		builder.WriteString(segment.String())
+	}
+
+	return builder.String()
+}
+
+type Problem struct {
+	// RuleID is stable across languages, e.g. StateNodeMissingTransition
+	RuleID   string
+	Severity Severity
+	// Path points to the offending field or value
+	Path []PathSegment
+	// Key is the name of the message template in the localization file, Args are its arguments
+	Key  string
+	Args []interface{}
+	Text string
+	// File and Position are set when the problem is found in a decoded source file
+	File     string
+	Position jsonsource.Position
+	// Related are other positions the problem refers to, e.g. the first occurrence of a duplicate key
+	Related []jsonsource.Position
+}
+
+// Location returns "file:line:col", only the file if the position is unknown, or an empty string
+func (p Problem) Location() string {
+	if !p.Position.IsValid() {
+		return p.File
+	}
+
+	return fmt.Sprintf("%s:%d:%d", p.File, p.Position.Line, p.Position.Column)
+}
+
+// NewProblem renders the message template key with args in the current language
+func NewProblem(ruleID string, severity Severity, path string, key string, args ...interface{}) Problem {
+	problem := Problem{
+		RuleID:   ruleID,
+		Severity: severity,
+		Path:     ParsePath(path),
+		Key:      key,
+		Args:     args,
+	}
+	problem.Text = problem.Render(localization.GetLocalizerOrPanic())
+
+	return problem
+}
+
+// Render returns the message of the problem in the language of localizer
+func (p Problem) Render(localizer localization.Localizer) string {
+	if p.Key == "" {
+		return p.Text
+	}
+
+	return fmt.Sprintf(localizer.GetString(p.Key), p.Args...)
+}
+
+func (p Problem) String() string {
+	return p.Text
+}
+
 type Problems struct {
-	problems []string
+	problems []Problem
 }
 
 func NewProblems() *Problems {
 	return &Problems{
-		problems: []string{},
+		problems: []Problem{},
 	}
 }
 
-func (p *Problems) Append(value string) {
-	p.problems = append(p.problems, value)
+func (p *Problems) Append(problem Problem) {
+	p.problems = append(p.problems, problem)
+}
+
+// Report appends a problem of rule ruleID at path with the message template named as the rule
+func (p *Problems) Report(ruleID string, path string, args ...interface{}) {
+	p.ReportKey(ruleID, path, ruleID, args...)
+}
+
+// ReportKey appends a problem of rule ruleID at path with the message template key,
+// for rules that word their message differently depending on the case
+func (p *Problems) ReportKey(ruleID string, path string, key string, args ...interface{}) {
+	p.Append(NewProblem(ruleID, ruleSeverity(ruleID), path, key, args...))
+}
+
+// AppendError appends an error that stopped the linter before or during reading the input
+func (p *Problems) AppendError(err error) {
+	p.Append(Problem{
+		RuleID:   ToolErrorRuleID,
+		Severity: ruleSeverity(ToolErrorRuleID),
+		Path:     []PathSegment{},
+		Text:     err.Error(),
+	})
+}
+
+// Locate sets the file of every problem and finds its position by the path from root,
+// unless the position is already known. The first segment of a path names root itself.
+func (p *Problems) Locate(file string, root Node) {
+	for i := range p.problems {
+		problem := &p.problems[i]
+		problem.File = file
+
+		if len(problem.Path) == 0 || problem.Position.IsValid() {
+			continue
+		}
+
+		if position, ok := root.Locate(problem.Path[1:]); ok {
+			problem.Position = position
+		}
+	}
 }
 
 func (p *Problems) Len() int {
@@ -22,6 +200,6 @@ func (p *Problems) Len() int {
 	return len(p.problems)
 }
 
-func (p *Problems) GetProblems() []string {
+func (p *Problems) GetProblems() []Problem {
 	return p.problems
 }
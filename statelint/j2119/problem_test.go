@@ -0,0 +1,157 @@
+package j2119
+
+import (
+	"github.com/stretchr/testify/assert"
+	"statelint/localization"
+	"testing"
+)
+
+func TestParsePath(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		path     string
+		segments []PathSegment
+	}{
+		{"", []PathSegment{}},
+		{"State Machine", []PathSegment{{Field: "State Machine"}}},
+		{
+			"State Machine.States.x.Retry[1]",
+			[]PathSegment{
+				{Field: "State Machine"},
+				{Field: "States"},
+				{Field: "x"},
+				{Field: "Retry"},
+				{Index: 1, IsIndex: true},
+			},
+		},
+		{
+			"a.b[0][2].c",
+			[]PathSegment{
+				{Field: "a"},
+				{Field: "b"},
+				{Index: 0, IsIndex: true},
+				{Index: 2, IsIndex: true},
+				{Field: "c"},
+			},
+		},
+	}
+
+	for _, testCase := range testCases {
+		segments := ParsePath(testCase.path)
+		assert.Equal(t, testCase.segments, segments, testCase.path)
+		assert.Equal(t, testCase.path, FormatPath(segments))
+	}
+}
+
+func TestStateNode_ProblemRecords(t *testing.T) {
+	t.Parallel()
+
+	json := `{
+			  "StartAt": "x",
+			  "States": {
+				"x": {
+				  "Type": "Pass",
+				  "Next": "y"
+				},
+				"z": {
+				  "Type": "Succeed"
+				}
+			  }
+			}`
+
+	node := NewNodeCreateHelper(t, json)
+	problems := NewProblems()
+	checker := NewStateNode()
+	checker.Check(node, "a", problems)
+
+	ruleIDs := make([]string, 0)
+	paths := make([]string, 0)
+
+	for _, problem := range problems.GetProblems() {
+		ruleIDs = append(ruleIDs, problem.RuleID)
+		paths = append(paths, FormatPath(problem.Path))
+
+		assert.Equal(t, SeverityError, problem.Severity)
+		assert.Equal(t, problem.Key, problem.RuleID)
+		assert.NotEmpty(t, problem.Text)
+	}
+
+	assert.ElementsMatch(t, []string{"StateNodeNoStateFoundReferenced", "StateNodeMissingTransition"}, ruleIDs)
+	assert.ElementsMatch(t, []string{"a.States.x.Next", "a.States.z"}, paths)
+}
+
+func TestFieldTypeConstraint_ProblemRecord(t *testing.T) {
+	t.Parallel()
+
+	c := NewFieldTypeConstraint("foo", String, false, false)
+	node := NewNodeCreateHelper(t, `{ "foo": 1 }`)
+	problems := NewProblems()
+
+	c.Check(node, "a", problems)
+
+	assert.Equal(t, 1, problems.Len())
+
+	problem := problems.GetProblems()[0]
+	assert.Equal(t, "FieldTypeConstraint", problem.RuleID)
+	assert.Equal(t, "FieldTypeConstraintReport", problem.Key)
+	assert.Equal(t, []PathSegment{{Field: "a"}, {Field: "foo"}}, problem.Path)
+	assert.Equal(t, problem.Text, problem.Render(localization.GetLocalizerOrPanic()))
+}
+
+func TestSeverity_AtLeast(t *testing.T) {
+	t.Parallel()
+
+	assert.True(t, SeverityError.AtLeast(SeverityError))
+	assert.True(t, SeverityError.AtLeast(SeverityWarning))
+	assert.True(t, SeverityWarning.AtLeast(SeverityWarning))
+	assert.False(t, SeverityWarning.AtLeast(SeverityError))
+}
+
+func TestStateNode_ProblemTextsKeepStatePaths(t *testing.T) {
+	t.Parallel()
+
+	linter, err := NewStateLinterFromFile("." + DefaultStateMachinePath)
+	if err != nil {
+		assert.Fail(t, err.Error())
+	}
+
+	testCases := []struct {
+		filename string
+		texts    map[string]string
+	}{
+		{
+			"parameterPathProblems.json",
+			map[string]string{
+				"State Machine.States.FNORD.Parameters.bad1.$": "Field \"bad1.$\" of \"Parameters\" at " +
+					"\"State Machine.FNORD\" is not a JSONPath or intrinsic function expression",
+				"State Machine.States.FNORD.Parameters.f3.f5[1].f7.bad4.$": "Field \"bad4.$\" of \"Parameters\" at " +
+					"\"State Machine.FNORD.f3.f5[1].f7\" is not a JSONPath or intrinsic function expression",
+			},
+		},
+		{
+			"passWithIntrinsicFunctionInputpath.json",
+			map[string]string{
+				"State Machine.States.p.InputPath": "Field \"InputPath\" defined at \"State Machine.p\" is not a JSONPath",
+			},
+		},
+		{
+			"mapWithNullItemspath.json",
+			map[string]string{
+				"State Machine.States.m.ItemsPath": "Field \"ItemsPath\" defined at \"State Machine.m\" should be non-null",
+			},
+		},
+	}
+
+	for _, testCase := range testCases {
+		texts := make(map[string]string)
+
+		for _, problem := range linter.ValidateJSONStruct(GetJSONObjectFromFile(t, testCase.filename)).GetProblems() {
+			texts[FormatPath(problem.Path)] = problem.Text
+		}
+
+		for path, text := range testCase.texts {
+			assert.Equal(t, text, texts[path], testCase.filename)
+		}
+	}
+}
@@ -0,0 +1,60 @@
+package j2119
+
+import "statelint/localization"
+
+// Rule describes a check. Problems found by the check have RuleID equal to ID.
+type Rule struct {
+	ID       string
+	Severity Severity
+}
+
+// Description returns the one-line description of the rule in the current language,
+// or the rule ID if the localization is not loaded
+func (r Rule) Description() string {
+	return localization.GetStringOrDefault("Rule"+r.ID, r.ID)
+}
+
+// Rules lists every rule statelint reports, in a stable order
+var Rules = []Rule{
+	{ID: "OnlyOneConstraint", Severity: SeverityError},
+	{ID: "NonEmptyConstraint", Severity: SeverityError},
+	{ID: "HasFieldConstraint", Severity: SeverityError},
+	{ID: "DoesNotHaveFieldConstraint", Severity: SeverityError},
+	{ID: "FieldTypeConstraint", Severity: SeverityError},
+	{ID: "FieldValueConstraint", Severity: SeverityError},
+	{ID: "NodeValidatorIsFieldAllowed", Severity: SeverityError},
+	{ID: "StateNodeDoesntHaveStartAtNode", Severity: SeverityError},
+	{ID: "StateNodeDoubleDefinedState", Severity: SeverityError},
+	{ID: "StateNodeMissingTransition", Severity: SeverityError},
+	{ID: "StateNodeNoStateFoundReferenced", Severity: SeverityError},
+	{ID: "StateNodeFieldShouldBeNonNull", Severity: SeverityError},
+	{ID: "StateNodeFieldIsNotJSONPath", Severity: SeverityError},
+	{ID: "StateNodeProbChoiceState", Severity: SeverityError},
+	{ID: "StateNodeProbePayloadBuilder", Severity: SeverityError},
+	{ID: "StateNodeCheckForTerminal", Severity: SeverityError},
+	{ID: "StateNodeCheckStatesAll", Severity: SeverityError},
+	// the machine is valid, but its execution fails with States.NoChoiceMatched if no choice rule matches
+	{ID: "StateNodeChoiceWithoutDefault", Severity: SeverityWarning},
+	{ID: "DuplicateKey", Severity: SeverityError},
+	{ID: ToolErrorRuleID, Severity: SeverityError},
+}
+
+// FindRule returns the rule with the given ID
+func FindRule(id string) (Rule, bool) {
+	for _, rule := range Rules {
+		if rule.ID == id {
+			return rule, true
+		}
+	}
+
+	return Rule{}, false
+}
+
+// ruleSeverity returns the severity of the rule, or error for an unknown rule
+func ruleSeverity(id string) Severity {
+	if rule, ok := FindRule(id); ok {
+		return rule.Severity
+	}
+
+	return SeverityError
+}
@@ -1,5 +1,10 @@
 package j2119
 
+import (
+	"fmt"
+	"statelint/jsonsource"
+)
+
 const DefaultStateMachinePath = "./data/StateMachine.j2119"
 
 type StateLinter struct {
@@ -22,8 +27,21 @@ func NewStateLinterFromFile(path string) (*StateLinter, error) {
 }
 
 func (s *StateLinter) ValidateJSONStruct(jsonObject interface{}) *Problems {
-	node := *NewNode(jsonObject)
-	problems := s.validator.ValidateJSONStruct(jsonObject)
+	return s.validateNode(*NewNode(jsonObject))
+}
+
+// ValidateSource validates a decoded file and points every problem to its position in the file
+func (s *StateLinter) ValidateSource(file string, source *jsonsource.Value) *Problems {
+	node := *NewNodeFromSource(source)
+	problems := s.validateNode(node)
+	reportDuplicateKeys(source, s.validator.parser.root, problems)
+	problems.Locate(file, node)
+
+	return problems
+}
+
+func (s *StateLinter) validateNode(node Node) *Problems {
+	problems := s.validator.ValidateNode(node)
 
 	// additional check
 	stateNode := NewStateNode()
@@ -31,3 +49,31 @@ func (s *StateLinter) ValidateJSONStruct(jsonObject interface{}) *Problems {
 
 	return problems
 }
+
+// reportDuplicateKeys reports every key that repeats in an object of source.
+// encoding/json silently keeps the last value of such key.
+func reportDuplicateKeys(source *jsonsource.Value, path string, problems *Problems) {
+	for _, duplicate := range source.Duplicates {
+		problem := NewProblem(
+			"DuplicateKey",
+			ruleSeverity("DuplicateKey"),
+			fmt.Sprintf("%s.%s", path, duplicate.Key),
+			"DuplicateKey",
+			duplicate.Key,
+			path,
+			duplicate.First.Line,
+			duplicate.First.Column,
+		)
+		problem.Position = duplicate.Position
+		problem.Related = []jsonsource.Position{duplicate.First}
+		problems.Append(problem)
+	}
+
+	for _, key := range source.Keys {
+		reportDuplicateKeys(source.Fields[key], fmt.Sprintf("%s.%s", path, key), problems)
+	}
+
+	for i, item := range source.Items {
+		reportDuplicateKeys(item, fmt.Sprintf("%s[%d]", path, i), problems)
+	}
+}
@@ -4,6 +4,7 @@ import (
 	"encoding/json"
 	"io"
 	"os"
+	"statelint/jsonsource"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
@@ -63,6 +64,7 @@ func TestStateLinter_JSONRightValidation(t *testing.T) {
 		{"parallelWithResultpath.json", 0},
 		{"passWithIoPathContextObject.json", 0},
 		{"choiceWithContextObject.json", 0},
+		{"choiceWithoutDefault.json", 1},
 		{"mapWithItemspathContextObject.json", 0},
 		{"taskWithDynamicTimeouts.json", 0},
 		{"passWithNullInputpath.json", 0},
@@ -105,3 +107,78 @@ func TestStateLinter_JSONRightValidation(t *testing.T) {
 		)
 	}
 }
+
+func TestStateLinter_ValidateSourceLocatesProblems(t *testing.T) {
+	t.Parallel()
+
+	linter, err := NewStateLinterFromFile("." + DefaultStateMachinePath)
+	if err != nil {
+		assert.Fail(t, err.Error())
+	}
+
+	data, err := os.ReadFile("../testdata/linkedParallel.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	source, err := jsonsource.Decode(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	locations := make(map[string]string)
+	for _, problem := range linter.ValidateSource("linkedParallel.json", source).GetProblems() {
+		locations[FormatPath(problem.Path)] = problem.Location()
+	}
+
+	assert.Equal(t, map[string]string{
+		"State Machine.States.A.Branches[0].States":             "linkedParallel.json:9:11",
+		"State Machine.States.A.Branches[0].States.Sub1_1.Next": "linkedParallel.json:12:15",
+		"State Machine.States.X.Choices[0].Next":                "linkedParallel.json:33:11",
+		"State Machine.States.X.Default":                        "linkedParallel.json:41:7",
+	}, locations)
+}
+
+func TestStateLinter_ValidateSourceFindsDuplicateKeys(t *testing.T) {
+	t.Parallel()
+
+	linter, err := NewStateLinterFromFile("." + DefaultStateMachinePath)
+	if err != nil {
+		assert.Fail(t, err.Error())
+	}
+
+	data := `{
+  "StartAt": "A",
+  "States": {
+    "A": {"Type": "Pass", "Next": "B", "Next": "A"},
+    "B": {"Type": "Succeed"},
+    "B": {"Type": "Succeed"}
+  }
+}`
+
+	source, err := jsonsource.Decode([]byte(data))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	duplicates := make(map[string]Problem)
+
+	for _, problem := range linter.ValidateSource("dupes.json", source).GetProblems() {
+		if problem.RuleID == "DuplicateKey" {
+			duplicates[FormatPath(problem.Path)] = problem
+		}
+	}
+
+	assert.Len(t, duplicates, 2)
+
+	next := duplicates["State Machine.States.A.Next"]
+	assert.Equal(t, SeverityError, next.Severity)
+	assert.Equal(t, "dupes.json:4:40", next.Location())
+	assert.Equal(t, 4, next.Related[0].Line)
+	assert.Equal(t, 27, next.Related[0].Column)
+
+	state := duplicates["State Machine.States.B"]
+	assert.Equal(t, "dupes.json:6:5", state.Location())
+	assert.Equal(t, 5, state.Related[0].Line)
+	assert.Equal(t, 5, state.Related[0].Column)
+}
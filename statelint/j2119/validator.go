@@ -25,7 +25,11 @@ func NewValidator(assertionSourcePath string) (*Validator, error) {
 }
 
 func (v *Validator) ValidateJSONStruct(json interface{}) *Problems {
-	v.currentNode = NewNode(json)
+	return v.ValidateNode(*NewNode(json))
+}
+
+func (v *Validator) ValidateNode(node Node) *Problems {
+	v.currentNode = &node
 	problems := NewProblems()
 
 	validator := NewNodeValidator(v.parser)
@@ -0,0 +1,239 @@
+package jsonsource
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+	"unicode/utf8"
+)
+
+var ErrTrailingData = errors.New("unexpected data after top-level value")
+
+// Position points into the source: Offset is a byte offset, Line and Column start from 1.
+// Column counts characters, not bytes.
+type Position struct {
+	Offset int
+	Line   int
+	Column int
+}
+
+// IsValid reports whether the position was taken from a source
+func (p Position) IsValid() bool {
+	return p.Line > 0
+}
+
+func (p Position) String() string {
+	return fmt.Sprintf("%d:%d", p.Line, p.Column)
+}
+
+// Value is a decoded JSON value together with its position in the source
+type Value struct {
+	// Data is the value in the form encoding/json decodes it into interface{}
+	Data     interface{}
+	Position Position
+	// Keys are object keys in the source order, KeyPositions point at the quoted keys.
+	// Like encoding/json, a repeated key keeps its last value, so KeyPositions point at the last occurrence.
+	Keys         []string
+	KeyPositions map[string]Position
+	Fields       map[string]*Value
+	Items        []*Value
+	// Duplicates lists every repeated key of the object
+	Duplicates []DuplicateKey
+}
+
+// DuplicateKey is a key that occurs in an object again at Position after it first occurred at First
+type DuplicateKey struct {
+	Key      string
+	First    Position
+	Position Position
+}
+
+// Decode parses data like json.Unmarshal into interface{}, keeping the position of every value and key
+func Decode(data []byte) (*Value, error) {
+	d := &decoder{
+		data:  data,
+		json:  json.NewDecoder(bytes.NewReader(data)),
+		lines: lineStarts(data),
+	}
+	d.json.UseNumber()
+
+	value, err := d.decodeNext()
+	if err != nil {
+		return nil, err
+	}
+
+	if _, err := d.next(); !errors.Is(err, io.EOF) {
+		if err != nil {
+			return nil, err
+		}
+
+		return nil, d.errorAt(d.start, ErrTrailingData)
+	}
+
+	return value, nil
+}
+
+type decoder struct {
+	data  []byte
+	json  *json.Decoder
+	lines []int
+	start int
+}
+
+// next reads the next token and remembers where it starts
+func (d *decoder) next() (json.Token, error) {
+	d.start = d.skip(int(d.json.InputOffset()))
+
+	token, err := d.json.Token()
+	if err != nil && !errors.Is(err, io.EOF) {
+		return nil, d.errorAt(d.start, err)
+	}
+
+	return token, err
+}
+
+func (d *decoder) decodeNext() (*Value, error) {
+	token, err := d.next()
+	if errors.Is(err, io.EOF) {
+		return nil, d.errorAt(d.start, io.ErrUnexpectedEOF)
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	value := &Value{Position: d.position(d.start)}
+
+	switch token := token.(type) {
+	case json.Delim:
+		if token == '{' {
+			return value, d.decodeObject(value)
+		}
+
+		return value, d.decodeArray(value)
+	case json.Number:
+		number, err := token.Float64()
+		if err != nil {
+			return nil, d.errorAt(d.start, err)
+		}
+
+		value.Data = number
+	default:
+		value.Data = token
+	}
+
+	return value, nil
+}
+
+func (d *decoder) decodeObject(value *Value) error {
+	object := make(map[string]interface{})
+	value.Keys = make([]string, 0)
+	value.KeyPositions = make(map[string]Position)
+	value.Fields = make(map[string]*Value)
+	first := make(map[string]Position)
+
+	for d.json.More() {
+		token, err := d.next()
+		if err != nil {
+			return err
+		}
+
+		key, _ := token.(string)
+		keyPosition := d.position(d.start)
+
+		field, err := d.decodeNext()
+		if err != nil {
+			return err
+		}
+
+		if firstPosition, ok := first[key]; ok {
+			value.Duplicates = append(value.Duplicates, DuplicateKey{
+				Key:      key,
+				First:    firstPosition,
+				Position: keyPosition,
+			})
+		} else {
+			first[key] = keyPosition
+			value.Keys = append(value.Keys, key)
+		}
+
+		value.KeyPositions[key] = keyPosition
+		value.Fields[key] = field
+		object[key] = field.Data
+	}
+
+	value.Data = object
+
+	// closing brace
+	_, err := d.next()
+
+	return err
+}
+
+func (d *decoder) decodeArray(value *Value) error {
+	array := make([]interface{}, 0)
+	value.Items = make([]*Value, 0)
+
+	for d.json.More() {
+		item, err := d.decodeNext()
+		if err != nil {
+			return err
+		}
+
+		value.Items = append(value.Items, item)
+		array = append(array, item.Data)
+	}
+
+	value.Data = array
+
+	// closing bracket
+	_, err := d.next()
+
+	return err
+}
+
+// skip moves offset past whitespace and separators, which the json decoder consumes lazily
+func (d *decoder) skip(offset int) int {
+	for offset < len(d.data) {
+		switch d.data[offset] {
+		case ' ', '\t', '\r', '\n', ',', ':':
+			offset++
+		default:
+			return offset
+		}
+	}
+
+	return offset
+}
+
+func (d *decoder) position(offset int) Position {
+	line := sort.Search(len(d.lines), func(i int) bool { return d.lines[i] > offset }) - 1
+
+	return Position{
+		Offset: offset,
+		Line:   line + 1,
+		Column: utf8.RuneCount(d.data[d.lines[line]:offset]) + 1,
+	}
+}
+
+func (d *decoder) errorAt(offset int, err error) error {
+	position := d.position(offset)
+
+	return fmt.Errorf("line %d, column %d: %w", position.Line, position.Column, err)
+}
+
+// lineStarts returns byte offsets where lines begin
+func lineStarts(data []byte) []int {
+	lines := []int{0}
+
+	for i, c := range data {
+		if c == '\n' {
+			lines = append(lines, i+1)
+		}
+	}
+
+	return lines
+}
@@ -0,0 +1,107 @@
+package jsonsource
+
+import (
+	"encoding/json"
+	"errors"
+	"github.com/stretchr/testify/assert"
+	"os"
+	"strings"
+	"testing"
+)
+
+func TestDecode_SameDataAsUnmarshal(t *testing.T) {
+	t.Parallel()
+
+	testCases := []string{
+		"../testdata/good.json",
+		"../testdata/linkedParallel.json",
+		"../testdata/parameterPathProblems.json",
+	}
+
+	for _, testCase := range testCases {
+		data, err := os.ReadFile(testCase)
+		if err != nil {
+			t.Fatalf("can not read file \"%s\": %s", testCase, err)
+		}
+
+		var expected interface{}
+		if err := json.Unmarshal(data, &expected); err != nil {
+			t.Fatalf("can not unmarshal file \"%s\": %s", testCase, err)
+		}
+
+		value, err := Decode(data)
+		if err != nil {
+			t.Fatalf("can not decode file \"%s\": %s", testCase, err)
+		}
+
+		assert.Equal(t, expected, value.Data, testCase)
+	}
+}
+
+func TestDecode_Positions(t *testing.T) {
+	t.Parallel()
+
+	data := "{\r\n  \"Ключ\": [1, {\"b\": null}],\r\n\t\"c\" : true\n}"
+
+	value, err := Decode([]byte(data))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	assert.Equal(t, Position{Offset: 0, Line: 1, Column: 1}, value.Position)
+	assert.Equal(t, []string{"Ключ", "c"}, value.Keys)
+	assert.Equal(t, Position{Offset: 5, Line: 2, Column: 3}, value.KeyPositions["Ключ"])
+
+	array := value.Fields["Ключ"]
+	assert.Equal(t, 2, array.Position.Line)
+	assert.Equal(t, 11, array.Position.Column)
+	assert.Equal(t, 12, array.Items[0].Position.Column)
+	assert.Equal(t, 15, array.Items[1].Position.Column)
+	assert.Equal(t, 21, array.Items[1].Fields["b"].Position.Column)
+
+	assert.Equal(t, Position{Offset: 37, Line: 3, Column: 2}, value.KeyPositions["c"])
+	assert.Equal(t, Position{Offset: 43, Line: 3, Column: 8}, value.Fields["c"].Position)
+}
+
+func TestDecode_Errors(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		data     string
+		position string
+	}{
+		{"", "line 1, column 1: "},
+		{"{\n  \"a\": 1,\n}", "line 3, column 1: "},
+		{"{\"a\" 1}", "line 1, column 6: "},
+		{"{} {}", "line 1, column 4: "},
+	}
+
+	for _, testCase := range testCases {
+		_, err := Decode([]byte(testCase.data))
+		if assert.Error(t, err, testCase.data) {
+			assert.True(t, strings.HasPrefix(err.Error(), testCase.position), err.Error())
+		}
+	}
+
+	_, err := Decode([]byte("{} {}"))
+	assert.True(t, errors.Is(err, ErrTrailingData))
+}
+
+func TestDecode_DuplicateKeys(t *testing.T) {
+	t.Parallel()
+
+	data := `{"Next": "A", "Type": "Pass", "Next": "B", "Next": "C"}`
+
+	value, err := Decode([]byte(data))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	assert.Equal(t, map[string]interface{}{"Next": "C", "Type": "Pass"}, value.Data)
+	assert.Equal(t, []string{"Next", "Type"}, value.Keys)
+	assert.Equal(t, 44, value.KeyPositions["Next"].Column)
+	assert.Equal(t, []DuplicateKey{
+		{Key: "Next", First: Position{Offset: 1, Line: 1, Column: 2}, Position: Position{Offset: 30, Line: 1, Column: 31}},
+		{Key: "Next", First: Position{Offset: 1, Line: 1, Column: 2}, Position: Position{Offset: 43, Line: 1, Column: 44}},
+	}, value.Duplicates)
+}
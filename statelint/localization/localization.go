@@ -4,8 +4,8 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
+	"io/fs"
 	"os"
-	path2 "path"
 	"sync"
 )
 
@@ -24,23 +24,31 @@ type Localizer interface {
 	SetLocalization(lang string) error
 	// GetString returns string by given name
 	GetString(name string) string
+	// LookupString returns string by given name and reports whether it exists
+	LookupString(name string) (string, bool)
 }
 
 type localizer struct {
 	currentLanguage string
-	folder          string
+	files           fs.FS
 	jsonFile        map[string]string
 	mu              sync.RWMutex
 }
 
 func GetLocalizerFromFile(localizationFolder string) (Localizer, error) {
+	return GetLocalizerFromFS(os.DirFS(localizationFolder))
+}
+
+// GetLocalizerFromFS loads language files named <lang>.json from the root of files,
+// e.g. from files embedded into the binary
+func GetLocalizerFromFS(files fs.FS) (Localizer, error) {
 	var err error
 
 	once.Do(func() {
 		singleton = &localizer{
 			currentLanguage: "",
 			jsonFile:        nil,
-			folder:          localizationFolder,
+			files:           files,
 			mu:              sync.RWMutex{},
 		}
 		err = singleton.SetLocalization(DefaultLanguage)
@@ -66,6 +74,21 @@ func GetLocalizerOrPanic() Localizer {
 	return l
 }
 
+// GetStringOrDefault returns string by given name, or fallback if the localization is not loaded
+// or has no such string. Use it where a panic would hide the error being reported.
+func GetStringOrDefault(name string, fallback string) string {
+	l, err := GetLocalizer()
+	if err != nil {
+		return fallback
+	}
+
+	if str, ok := l.LookupString(name); ok {
+		return str
+	}
+
+	return fallback
+}
+
 func (l *localizer) SetLocalization(lang string) error {
 	l.mu.Lock()
 	defer l.mu.Unlock()
@@ -90,19 +113,23 @@ func (l *localizer) GetString(name string) string {
 	panic(fmt.Sprintf("can not find string %s in %s.json file", name, l.currentLanguage))
 }
 
-func (l *localizer) loadLocalization() error {
-	pathToFile := path2.Join(l.folder, l.currentLanguage+".json")
+func (l *localizer) LookupString(name string) (string, bool) {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
 
-	exists, err := fileExists(pathToFile)
-	if err != nil {
-		return err
-	}
+	str, ok := l.jsonFile[name]
+
+	return str, ok
+}
+
+func (l *localizer) loadLocalization() error {
+	pathToFile := l.currentLanguage + ".json"
 
-	if !exists {
+	data, err := fs.ReadFile(l.files, pathToFile)
+	if errors.Is(err, fs.ErrNotExist) {
 		return errors.New("can not find localization file")
 	}
 
-	data, err := os.ReadFile(pathToFile)
 	if err != nil {
 		return fmt.Errorf("can not read localization file %s: %w", pathToFile, err)
 	}
@@ -118,16 +145,3 @@ func (l *localizer) loadLocalization() error {
 
 	return nil
 }
-
-func fileExists(path string) (bool, error) {
-	_, err := os.Stat(path)
-	if err == nil {
-		return true, nil
-	}
-
-	if errors.Is(err, os.ErrNotExist) {
-		return false, nil
-	}
-
-	return false, fmt.Errorf("error when check filepath existence %s, err: %w", path, err)
-}
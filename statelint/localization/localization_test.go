@@ -3,6 +3,7 @@ package localization
 import (
 	"sync"
 	"testing"
+	"testing/fstest"
 )
 
 const testDefaulLocalizationFolder = "../langs"
@@ -109,3 +110,30 @@ func TestLocalizer_SetUndefinedLocalization(t *testing.T) {
 		t.Fatal("should return err, but err is nil")
 	}
 }
+
+// nolint:paralleltest
+func TestLocalizer_InitFromFS(t *testing.T) {
+	t.Cleanup(cleanup)
+
+	files := fstest.MapFS{
+		"en.json": {Data: []byte(`{"Hello": "Hello"}`)},
+		"ru.json": {Data: []byte(`{"Hello": "Привет"}`)},
+	}
+
+	l, err := GetLocalizerFromFS(files)
+	if err != nil {
+		t.Fatalf("should init without err, but have: %s", err.Error())
+	}
+
+	if err := l.SetLocalization("ru"); err != nil {
+		t.Fatalf("should not return err, but have: %s", err.Error())
+	}
+
+	if str, ok := l.LookupString("Hello"); !ok || str != "Привет" {
+		t.Fatalf("should find localized string, but have: %q, %v", str, ok)
+	}
+
+	if _, ok := l.LookupString("Missing"); ok {
+		t.Fatal("should not find missing string")
+	}
+}
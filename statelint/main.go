@@ -1,14 +1,18 @@
-package statelint
+package main
 
 import (
+	"errors"
 	"flag"
 	"fmt"
+	"io"
 	"log"
 	"os"
 	config2 "statelint/config"
 	"statelint/j2119"
 	"statelint/localization"
+	"statelint/output"
 	"statelint/reader"
+	"strings"
 )
 
 var (
@@ -23,6 +27,13 @@ var (
 	localFileUsage = "path to local json file. Can be reference or absolute."
 	localFilePath  = flag.String("local_file", "", localFileUsage)
 
+	formatUsage = fmt.Sprintf("Sets the output format, one of: %s", strings.Join(output.Formats(), ", "))
+	format      = flag.String("format", output.FormatText, formatUsage)
+
+	failOnUsage = fmt.Sprintf("Sets the least severity of problems that fail the check, one of: %s, %s, %s",
+		j2119.SeverityError, j2119.SeverityWarning, failOnNone)
+	failOn = flag.String("fail-on", string(j2119.SeverityError), failOnUsage)
+
 	help = flag.Bool("help", false, "print this help")
 )
 
@@ -30,34 +41,72 @@ func init() {
 	flag.StringVar(language, "l", "en", languageUsage)
 	flag.StringVar(minioFilePath, "mf", "", minioFileUsage)
 	flag.StringVar(localFilePath, "lf", "", localFileUsage)
+	flag.StringVar(format, "f", output.FormatText, formatUsage)
 }
 
+const (
+	exitOK        = 0
+	exitProblems  = 1
+	exitToolError = 2
+)
+
+// failOnNone is the --fail-on value that never fails the check because of problems
+const failOnNone = "none"
+
+var (
+	ErrUnknownFailOn = errors.New("unknown fail-on value")
+	ErrNoInput       = errors.New("no input file, set -local_file or -minio_file")
+)
+
 func main() {
-	flag.Parse()
+	os.Exit(run(os.Args[1:], os.Stdout))
+}
+
+// run checks the input given by command line args and returns the exit code.
+// Problems are printed to stdout before run returns.
+func run(args []string, stdout io.Writer) (code int) {
+	if err := flag.CommandLine.Parse(args); err != nil {
+		return exitToolError
+	}
 
 	if *help {
 		flag.PrintDefaults()
+		fmt.Fprintf(stdout, "Exit codes: %d - no problems, %d - problems found, %d - tool or config error\n",
+			exitOK, exitProblems, exitToolError)
 
-		return
+		return exitOK
 	}
 
 	problems := j2119.NewProblems()
 	defer func() {
-		if problems.Len() != 0 {
-			problemsCountStr := fmt.Sprintf(localization.GetLocalizerOrPanic().
-				GetString("ProblemsCount"),
-				problems.Len())
-			fmt.Fprintln(os.Stdout, problemsCountStr)
+		file := *localFilePath
+		if *minioFilePath != "" {
+			file = *minioFilePath
 		}
 
-		for _, p := range problems.GetProblems() {
-			_, err := fmt.Fprintln(os.Stdout, p)
-			if err != nil {
-				log.Fatalf("can not write to stdout %s", err.Error())
-			}
+		code = exitCode(problems)
+
+		err := output.Write(stdout, *format, file, problems.GetProblems())
+		if err != nil {
+			log.Printf("can not write to stdout %s", err.Error())
+
+			code = exitToolError
 		}
 	}()
 
+	if err := output.CheckFormat(*format); err != nil {
+		*format = output.FormatText
+		problems.AppendError(err)
+
+		return
+	}
+
+	if err := checkFailOn(*failOn); err != nil {
+		problems.AppendError(err)
+
+		return
+	}
+
 	stateLint, hasError := setup(problems)
 	if hasError {
 		return
@@ -67,49 +116,85 @@ func main() {
 	case *minioFilePath != "":
 		config, err := config2.ReadConfig()
 		if err != nil {
-			problems.Append(err.Error())
+			problems.AppendError(err)
 
 			return
 		}
 
 		json, err := reader.GetJSONFromMinio(config.MinioConfig, *minioFilePath)
 		if err != nil {
-			problems.Append(err.Error())
+			problems.AppendError(err)
 
 			return
 		}
 
-		problems = stateLint.ValidateJSONStruct(json)
+		problems = stateLint.ValidateSource(*minioFilePath, json)
 	case *localFilePath != "":
 		json, err := reader.GetJSONFromLocalFile(*localFilePath)
 		if err != nil {
-			problems.Append(err.Error())
+			problems.AppendError(err)
 
 			return
 		}
 
-		problems = stateLint.ValidateJSONStruct(json)
+		problems = stateLint.ValidateSource(*localFilePath, json)
+	default:
+		problems.AppendError(ErrNoInput)
+	}
+
+	return
+}
+
+func checkFailOn(value string) error {
+	switch value {
+	case string(j2119.SeverityError), string(j2119.SeverityWarning), failOnNone:
+		return nil
 	}
+
+	return fmt.Errorf("%w \"%s\", should be one of %s, %s, %s",
+		ErrUnknownFailOn, value, j2119.SeverityError, j2119.SeverityWarning, failOnNone)
+}
+
+// exitCode returns exitToolError if the input or the configuration could not be read,
+// exitProblems if there is a problem at least as severe as --fail-on, and exitOK otherwise
+func exitCode(problems *j2119.Problems) int {
+	for _, problem := range problems.GetProblems() {
+		if problem.RuleID == j2119.ToolErrorRuleID {
+			return exitToolError
+		}
+	}
+
+	if *failOn == failOnNone {
+		return exitOK
+	}
+
+	for _, problem := range problems.GetProblems() {
+		if problem.Severity.AtLeast(j2119.Severity(*failOn)) {
+			return exitProblems
+		}
+	}
+
+	return exitOK
 }
 
 func setup(problems *j2119.Problems) (*j2119.StateLinter, bool) {
 	localizer, err := localization.GetLocalizer()
 	if err != nil {
-		problems.Append(err.Error())
+		problems.AppendError(err)
 
 		return nil, true
 	}
 
 	err = localizer.SetLocalization(*language)
 	if err != nil {
-		problems.Append(err.Error())
+		problems.AppendError(err)
 
 		return nil, true
 	}
 
 	stateLint, err := j2119.NewStateLinter()
 	if err != nil {
-		problems.Append(err.Error())
+		problems.AppendError(err)
 
 		return nil, true
 	}
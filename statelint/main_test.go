@@ -0,0 +1,85 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"github.com/stretchr/testify/assert"
+	"statelint/j2119"
+	"testing"
+)
+
+// runWith runs the checker with default values of all flags overridden by args
+func runWith(args ...string) (int, string) {
+	var stdout bytes.Buffer
+
+	defaults := []string{"-l=en", "-lf=", "-mf=", "-f=text", "-fail-on=error", "-help=false"}
+	code := run(append(defaults, args...), &stdout)
+
+	return code, stdout.String()
+}
+
+func TestRun(t *testing.T) {
+	testCases := []struct {
+		name   string
+		args   []string
+		code   int
+		output string
+	}{
+		{"no problems", []string{"-lf", "testdata/good.json"}, exitOK, ""},
+		{
+			"problems",
+			[]string{"-lf", "testdata/failWithParameters.json"},
+			exitProblems,
+			"testdata/failWithParameters.json:6:7: Field \"Parameters\" not allowed in State Machine.States.p",
+		},
+		{"fail on none", []string{"-lf", "testdata/failWithParameters.json", "-fail-on", "none"}, exitOK, "Parameters"},
+		{"warning", []string{"-lf", "testdata/choiceWithoutDefault.json"}, exitOK, "has no Default"},
+		{"fail on warning", []string{"-lf", "testdata/choiceWithoutDefault.json", "-fail-on", "warning"}, exitProblems, "has no Default"},
+		{"duplicate key", []string{"-lf", "testdata/duplicateKey.json"}, exitProblems, "Key \"Next\" is repeated"},
+		{"missing file", []string{"-lf", "testdata/missing.json"}, exitToolError, "testdata/missing.json"},
+		{"unknown format", []string{"-lf", "testdata/good.json", "-f", "yaml"}, exitToolError, "unknown output format"},
+		{"unknown fail-on", []string{"-lf", "testdata/good.json", "-fail-on", "info"}, exitToolError, "unknown fail-on value"},
+		{"unknown language", []string{"-lf", "testdata/good.json", "-l", "xx"}, exitToolError, "localization"},
+		{"no input", []string{}, exitToolError, "no input file"},
+		{"help", []string{"-help"}, exitOK, "Exit codes"},
+	}
+
+	for _, testCase := range testCases {
+		code, stdout := runWith(testCase.args...)
+		assert.Equal(t, testCase.code, code, testCase.name)
+		assert.Contains(t, stdout, testCase.output, testCase.name)
+	}
+}
+
+func TestExitCode(t *testing.T) {
+	warning := j2119.Problem{RuleID: "Advisory", Severity: j2119.SeverityWarning}
+	failure := j2119.Problem{RuleID: "Failure", Severity: j2119.SeverityError}
+
+	testCases := []struct {
+		failOn   string
+		problems []j2119.Problem
+		code     int
+	}{
+		{"error", nil, exitOK},
+		{"error", []j2119.Problem{warning}, exitOK},
+		{"error", []j2119.Problem{warning, failure}, exitProblems},
+		{"warning", []j2119.Problem{warning}, exitProblems},
+		{"none", []j2119.Problem{warning, failure}, exitOK},
+	}
+
+	defer func(value string) { *failOn = value }(*failOn)
+
+	for _, testCase := range testCases {
+		*failOn = testCase.failOn
+
+		problems := j2119.NewProblems()
+		for _, problem := range testCase.problems {
+			problems.Append(problem)
+		}
+
+		assert.Equal(t, testCase.code, exitCode(problems), testCase.failOn)
+
+		problems.AppendError(errors.New("can not read config"))
+		assert.Equal(t, exitToolError, exitCode(problems), testCase.failOn)
+	}
+}
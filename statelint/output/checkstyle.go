@@ -0,0 +1,53 @@
+package output
+
+import (
+	"encoding/xml"
+	"io"
+	"statelint/j2119"
+)
+
+const checkstyleVersion = "4.3"
+
+type checkstyleReport struct {
+	XMLName xml.Name         `xml:"checkstyle"`
+	Version string           `xml:"version,attr"`
+	Files   []checkstyleFile `xml:"file"`
+}
+
+type checkstyleFile struct {
+	Name   string            `xml:"name,attr"`
+	Errors []checkstyleError `xml:"error"`
+}
+
+type checkstyleError struct {
+	Line     int    `xml:"line,attr"`
+	Column   int    `xml:"column,attr,omitempty"`
+	Severity string `xml:"severity,attr"`
+	Message  string `xml:"message,attr"`
+	// Source is the rule, e.g. statelint.StateNodeMissingTransition
+	Source string `xml:"source,attr"`
+}
+
+// writeCheckstyle prints a Checkstyle report. Problems without a position are reported at line 0.
+func writeCheckstyle(w io.Writer, file string, problems []j2119.Problem) error {
+	report := checkstyleReport{Version: checkstyleVersion}
+	files, grouped := groupByFile(file, problems)
+
+	for _, name := range files {
+		checkstyle := checkstyleFile{Name: name}
+
+		for _, problem := range grouped[name] {
+			checkstyle.Errors = append(checkstyle.Errors, checkstyleError{
+				Line:     problem.Position.Line,
+				Column:   problem.Position.Column,
+				Severity: string(problem.Severity),
+				Message:  problem.Text,
+				Source:   ToolName + "." + problem.RuleID,
+			})
+		}
+
+		report.Files = append(report.Files, checkstyle)
+	}
+
+	return writeXML(w, report)
+}
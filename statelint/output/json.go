@@ -0,0 +1,78 @@
+package output
+
+import (
+	"encoding/json"
+	"io"
+	"statelint/j2119"
+	"statelint/jsonsource"
+)
+
+type jsonProblem struct {
+	RuleID   string         `json:"ruleId"`
+	Severity j2119.Severity `json:"severity"`
+	// Path holds field names as strings and array indexes as numbers
+	Path     []interface{}  `json:"path"`
+	Key      string         `json:"key,omitempty"`
+	Args     []interface{}  `json:"args,omitempty"`
+	Message  string         `json:"message"`
+	File     string         `json:"file,omitempty"`
+	Position *jsonPosition  `json:"position,omitempty"`
+	Related  []jsonPosition `json:"related,omitempty"`
+}
+
+type jsonPosition struct {
+	Line   int `json:"line"`
+	Column int `json:"column"`
+	Offset int `json:"offset"`
+}
+
+// writeJSON prints an array of structured problems
+func writeJSON(w io.Writer, file string, problems []j2119.Problem) error {
+	result := make([]jsonProblem, 0, len(problems))
+
+	for _, problem := range problems {
+		item := jsonProblem{
+			RuleID:   problem.RuleID,
+			Severity: problem.Severity,
+			Path:     jsonPath(problem.Path),
+			Key:      problem.Key,
+			Args:     problem.Args,
+			Message:  problem.Text,
+			File:     problemFile(file, problem),
+		}
+
+		if problem.Position.IsValid() {
+			position := newJSONPosition(problem.Position)
+			item.Position = &position
+		}
+
+		for _, related := range problem.Related {
+			item.Related = append(item.Related, newJSONPosition(related))
+		}
+
+		result = append(result, item)
+	}
+
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+
+	return encoder.Encode(result)
+}
+
+func jsonPath(segments []j2119.PathSegment) []interface{} {
+	path := make([]interface{}, 0, len(segments))
+
+	for _, segment := range segments {
+		if segment.IsIndex {
+			path = append(path, segment.Index)
+		} else {
+			path = append(path, segment.Field)
+		}
+	}
+
+	return path
+}
+
+func newJSONPosition(position jsonsource.Position) jsonPosition {
+	return jsonPosition{Line: position.Line, Column: position.Column, Offset: position.Offset}
+}
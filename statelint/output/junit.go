@@ -0,0 +1,101 @@
+package output
+
+import (
+	"encoding/xml"
+	"fmt"
+	"io"
+	"statelint/j2119"
+)
+
+type junitTestSuites struct {
+	XMLName  xml.Name         `xml:"testsuites"`
+	Name     string           `xml:"name,attr"`
+	Tests    int              `xml:"tests,attr"`
+	Failures int              `xml:"failures,attr"`
+	Errors   int              `xml:"errors,attr"`
+	Suites   []junitTestSuite `xml:"testsuite"`
+}
+
+type junitTestSuite struct {
+	Name      string          `xml:"name,attr"`
+	Tests     int             `xml:"tests,attr"`
+	Failures  int             `xml:"failures,attr"`
+	Errors    int             `xml:"errors,attr"`
+	TestCases []junitTestCase `xml:"testcase"`
+}
+
+type junitTestCase struct {
+	Name      string        `xml:"name,attr"`
+	ClassName string        `xml:"classname,attr"`
+	Failure   *junitFailure `xml:"failure,omitempty"`
+	Error     *junitFailure `xml:"error,omitempty"`
+}
+
+type junitFailure struct {
+	Message string `xml:"message,attr"`
+	Type    string `xml:"type,attr"`
+	Text    string `xml:",chardata"`
+}
+
+// writeJUnit prints a test suite per file and a failed test case per problem.
+// A file without problems gets one passed test case. Tool errors are reported as errors, not failures.
+func writeJUnit(w io.Writer, file string, problems []j2119.Problem) error {
+	suites := junitTestSuites{Name: ToolName}
+	files, grouped := groupByFile(file, problems)
+
+	for _, name := range files {
+		suite := junitTestSuite{Name: name}
+
+		if len(grouped[name]) == 0 {
+			suite.TestCases = append(suite.TestCases, junitTestCase{Name: ToolName, ClassName: name})
+		}
+
+		for _, problem := range grouped[name] {
+			testCase := junitTestCase{Name: problem.RuleID, ClassName: name}
+			if len(problem.Path) != 0 {
+				testCase.Name = fmt.Sprintf("%s %s", problem.RuleID, j2119.FormatPath(problem.Path))
+			}
+
+			failure := &junitFailure{Message: problem.Text, Type: problem.RuleID, Text: problem.Text}
+
+			if location := problem.Location(); location != "" {
+				failure.Text = location + ": " + problem.Text
+			}
+
+			if problem.RuleID == j2119.ToolErrorRuleID {
+				testCase.Error = failure
+				suite.Errors++
+			} else {
+				testCase.Failure = failure
+				suite.Failures++
+			}
+
+			suite.TestCases = append(suite.TestCases, testCase)
+		}
+
+		suite.Tests = len(suite.TestCases)
+		suites.Tests += suite.Tests
+		suites.Failures += suite.Failures
+		suites.Errors += suite.Errors
+		suites.Suites = append(suites.Suites, suite)
+	}
+
+	return writeXML(w, suites)
+}
+
+func writeXML(w io.Writer, document interface{}) error {
+	if _, err := io.WriteString(w, xml.Header); err != nil {
+		return err
+	}
+
+	encoder := xml.NewEncoder(w)
+	encoder.Indent("", "  ")
+
+	if err := encoder.Encode(document); err != nil {
+		return err
+	}
+
+	_, err := io.WriteString(w, "\n")
+
+	return err
+}
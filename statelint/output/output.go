@@ -0,0 +1,81 @@
+package output
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"statelint/j2119"
+	"strings"
+)
+
+const (
+	FormatText       = "text"
+	FormatJSON       = "json"
+	FormatSARIF      = "sarif"
+	FormatJUnit      = "junit"
+	FormatCheckstyle = "checkstyle"
+)
+
+// ToolName names statelint in reports that identify the producing tool
+const ToolName = "statelint"
+
+var ErrUnknownFormat = errors.New("unknown output format")
+
+// writer renders problems found in file. Problems without their own file belong to file.
+type writer func(w io.Writer, file string, problems []j2119.Problem) error
+
+var writers = map[string]writer{
+	FormatText:       writeText,
+	FormatJSON:       writeJSON,
+	FormatSARIF:      writeSARIF,
+	FormatJUnit:      writeJUnit,
+	FormatCheckstyle: writeCheckstyle,
+}
+
+// Formats returns names of all output formats
+func Formats() []string {
+	return []string{FormatText, FormatJSON, FormatSARIF, FormatJUnit, FormatCheckstyle}
+}
+
+// CheckFormat returns ErrUnknownFormat if format is not one of Formats
+func CheckFormat(format string) error {
+	if _, ok := writers[format]; !ok {
+		return fmt.Errorf("%w \"%s\", should be one of %s", ErrUnknownFormat, format, strings.Join(Formats(), ", "))
+	}
+
+	return nil
+}
+
+// Write renders problems found in file to w in the given format
+func Write(w io.Writer, format string, file string, problems []j2119.Problem) error {
+	if err := CheckFormat(format); err != nil {
+		return err
+	}
+
+	return writers[format](w, file, problems)
+}
+
+func problemFile(file string, problem j2119.Problem) string {
+	if problem.File != "" {
+		return problem.File
+	}
+
+	return file
+}
+
+// groupByFile returns files in the order of first appearance and problems of each file
+func groupByFile(file string, problems []j2119.Problem) ([]string, map[string][]j2119.Problem) {
+	files := []string{file}
+	grouped := map[string][]j2119.Problem{file: {}}
+
+	for _, problem := range problems {
+		name := problemFile(file, problem)
+		if _, ok := grouped[name]; !ok {
+			files = append(files, name)
+		}
+
+		grouped[name] = append(grouped[name], problem)
+	}
+
+	return files, grouped
+}
@@ -0,0 +1,243 @@
+package output
+
+import (
+	"bytes"
+	"encoding/json"
+	"encoding/xml"
+	"errors"
+	"github.com/stretchr/testify/assert"
+	"log"
+	"os"
+	"os/exec"
+	"statelint/j2119"
+	"statelint/jsonsource"
+	"statelint/localization"
+	"testing"
+)
+
+// noLocalizationEnv makes the test binary skip loading the localization,
+// as it happens when statelint fails before its configuration is read
+const noLocalizationEnv = "STATELINT_TEST_NO_LOCALIZATION"
+
+func TestMain(m *testing.M) {
+	// set up localizer with default language
+	if os.Getenv(noLocalizationEnv) == "" {
+		_, err := localization.GetLocalizerFromFile("../langs")
+		if err != nil {
+			log.Fatal("can not init localizer")
+		}
+	}
+
+	os.Exit(m.Run())
+}
+
+func testProblems() []j2119.Problem {
+	transition := j2119.NewProblem(
+		"StateNodeMissingTransition",
+		j2119.SeverityError,
+		"State Machine.States.B",
+		"StateNodeMissingTransition",
+		"State Machine",
+		"B",
+	)
+	transition.File = "machine.json"
+	transition.Position = jsonsource.Position{Offset: 40, Line: 5, Column: 5}
+
+	duplicate := j2119.NewProblem(
+		"DuplicateKey",
+		j2119.SeverityError,
+		"State Machine.States.A.Retry[0].Next",
+		"DuplicateKey",
+		"Next",
+		"State Machine.States.A.Retry[0]",
+		3,
+		7,
+	)
+	duplicate.File = "machine.json"
+	duplicate.Position = jsonsource.Position{Offset: 30, Line: 3, Column: 20}
+	duplicate.Related = []jsonsource.Position{{Offset: 17, Line: 3, Column: 7}}
+
+	problems := j2119.NewProblems()
+	problems.Append(transition)
+	problems.Append(duplicate)
+	problems.AppendError(errors.New("can not read config"))
+
+	return problems.GetProblems()
+}
+
+func TestWrite_UnknownFormat(t *testing.T) {
+	t.Parallel()
+
+	var buffer bytes.Buffer
+
+	err := Write(&buffer, "yaml", "machine.json", testProblems())
+	assert.True(t, errors.Is(err, ErrUnknownFormat))
+	assert.Empty(t, buffer.String())
+}
+
+func TestWrite_Text(t *testing.T) {
+	t.Parallel()
+
+	var buffer bytes.Buffer
+
+	assert.NoError(t, Write(&buffer, FormatText, "machine.json", testProblems()))
+	assert.Equal(t, "There is 3 errors:\n"+
+		"machine.json:5:5: No transition found to state State Machine.B\n"+
+		"machine.json:3:20: Key \"Next\" is repeated in State Machine.States.A.Retry[0], "+
+		"it is first defined at line 3, column 7\n"+
+		"can not read config\n", buffer.String())
+
+	buffer.Reset()
+	assert.NoError(t, Write(&buffer, FormatText, "machine.json", nil))
+	assert.Empty(t, buffer.String())
+}
+
+func TestWrite_JSON(t *testing.T) {
+	t.Parallel()
+
+	var buffer bytes.Buffer
+
+	assert.NoError(t, Write(&buffer, FormatJSON, "machine.json", testProblems()))
+
+	var result []map[string]interface{}
+	assert.NoError(t, json.Unmarshal(buffer.Bytes(), &result))
+	assert.Len(t, result, 3)
+
+	assert.Equal(t, "DuplicateKey", result[1]["ruleId"])
+	assert.Equal(t, "error", result[1]["severity"])
+	assert.Equal(t, []interface{}{"State Machine", "States", "A", "Retry", 0.0, "Next"}, result[1]["path"])
+	assert.Equal(t, "DuplicateKey", result[1]["key"])
+	assert.Equal(t, []interface{}{"Next", "State Machine.States.A.Retry[0]", 3.0, 7.0}, result[1]["args"])
+	assert.Equal(t, map[string]interface{}{"line": 3.0, "column": 20.0, "offset": 30.0}, result[1]["position"])
+	assert.Equal(t, []interface{}{map[string]interface{}{"line": 3.0, "column": 7.0, "offset": 17.0}}, result[1]["related"])
+
+	assert.Equal(t, "ToolError", result[2]["ruleId"])
+	assert.Equal(t, "machine.json", result[2]["file"])
+	assert.Equal(t, []interface{}{}, result[2]["path"])
+	assert.NotContains(t, result[2], "position")
+
+	buffer.Reset()
+	assert.NoError(t, Write(&buffer, FormatJSON, "machine.json", nil))
+	assert.Equal(t, "[]\n", buffer.String())
+}
+
+func TestWrite_SARIF(t *testing.T) {
+	t.Parallel()
+
+	var buffer bytes.Buffer
+
+	assert.NoError(t, Write(&buffer, FormatSARIF, "machine.json", testProblems()))
+
+	var report sarifLog
+	assert.NoError(t, json.Unmarshal(buffer.Bytes(), &report))
+	assert.Equal(t, "2.1.0", report.Version)
+	assert.Len(t, report.Runs, 1)
+
+	run := report.Runs[0]
+	assert.Equal(t, "statelint", run.Tool.Driver.Name)
+	assert.Len(t, run.Tool.Driver.Rules, len(j2119.Rules))
+	assert.Len(t, run.Results, 3)
+
+	duplicate := run.Results[1]
+	rule := run.Tool.Driver.Rules[*duplicate.RuleIndex]
+	assert.Equal(t, "DuplicateKey", rule.ID)
+	assert.Equal(t, "A JSON object repeats a key", rule.ShortDescription.Text)
+	assert.Equal(t, "error", duplicate.Level)
+	assert.Equal(t, "machine.json", duplicate.Locations[0].PhysicalLocation.ArtifactLocation.URI)
+	assert.Equal(t, &sarifRegion{StartLine: 3, StartColumn: 20}, duplicate.Locations[0].PhysicalLocation.Region)
+	assert.Equal(t, "State Machine.States.A.Retry[0].Next", duplicate.Locations[0].LogicalLocations[0].FullyQualifiedName)
+	assert.Equal(t, &sarifRegion{StartLine: 3, StartColumn: 7}, duplicate.RelatedLocations[0].PhysicalLocation.Region)
+
+	toolError := run.Results[2]
+	assert.Nil(t, toolError.Locations[0].PhysicalLocation.Region)
+	assert.Nil(t, toolError.Locations[0].LogicalLocations)
+}
+
+func TestWrite_JUnit(t *testing.T) {
+	t.Parallel()
+
+	var buffer bytes.Buffer
+
+	assert.NoError(t, Write(&buffer, FormatJUnit, "machine.json", testProblems()))
+
+	var suites junitTestSuites
+	assert.NoError(t, xml.Unmarshal(buffer.Bytes(), &suites))
+	assert.Equal(t, 3, suites.Tests)
+	assert.Equal(t, 2, suites.Failures)
+	assert.Equal(t, 1, suites.Errors)
+	assert.Len(t, suites.Suites, 1)
+	assert.Equal(t, "machine.json", suites.Suites[0].Name)
+
+	testCase := suites.Suites[0].TestCases[0]
+	assert.Equal(t, "StateNodeMissingTransition State Machine.States.B", testCase.Name)
+	assert.Equal(t, "StateNodeMissingTransition", testCase.Failure.Type)
+	assert.Equal(t, "machine.json:5:5: No transition found to state State Machine.B", testCase.Failure.Text)
+	assert.Equal(t, "ToolError", suites.Suites[0].TestCases[2].Name)
+	assert.NotNil(t, suites.Suites[0].TestCases[2].Error)
+
+	buffer.Reset()
+	assert.NoError(t, Write(&buffer, FormatJUnit, "machine.json", nil))
+	assert.NoError(t, xml.Unmarshal(buffer.Bytes(), &suites))
+	assert.Equal(t, 1, suites.Tests)
+	assert.Equal(t, 0, suites.Failures)
+}
+
+func TestWrite_Checkstyle(t *testing.T) {
+	t.Parallel()
+
+	var buffer bytes.Buffer
+
+	assert.NoError(t, Write(&buffer, FormatCheckstyle, "machine.json", testProblems()))
+
+	var report checkstyleReport
+	assert.NoError(t, xml.Unmarshal(buffer.Bytes(), &report))
+	assert.Len(t, report.Files, 1)
+	assert.Equal(t, []checkstyleError{
+		{
+			Line:     5,
+			Column:   5,
+			Severity: "error",
+			Message:  "No transition found to state State Machine.B",
+			Source:   "statelint.StateNodeMissingTransition",
+		},
+		{
+			Line:     3,
+			Column:   20,
+			Severity: "error",
+			Message:  "Key \"Next\" is repeated in State Machine.States.A.Retry[0], it is first defined at line 3, column 7",
+			Source:   "statelint.DuplicateKey",
+		},
+		{
+			Severity: "error",
+			Message:  "can not read config",
+			Source:   "statelint.ToolError",
+		},
+	}, report.Files[0].Errors)
+}
+
+func TestWrite_ToolErrorWithoutLocalization(t *testing.T) {
+	if os.Getenv(noLocalizationEnv) == "" {
+		// run the test again in a process where the localization is never loaded
+		cmd := exec.Command(os.Args[0], "-test.run=^TestWrite_ToolErrorWithoutLocalization$")
+		cmd.Env = append(os.Environ(), noLocalizationEnv+"=1")
+		out, err := cmd.CombinedOutput()
+		assert.NoError(t, err, string(out))
+
+		return
+	}
+
+	problems := j2119.NewProblems()
+	problems.AppendError(errors.New("can not read config"))
+
+	for _, format := range Formats() {
+		var buffer bytes.Buffer
+
+		assert.NoError(t, Write(&buffer, format, "machine.json", problems.GetProblems()), format)
+		assert.Contains(t, buffer.String(), "can not read config", format)
+	}
+
+	var buffer bytes.Buffer
+
+	assert.NoError(t, Write(&buffer, FormatText, "machine.json", problems.GetProblems()))
+	assert.Equal(t, "There is 1 errors:\ncan not read config\n", buffer.String())
+}
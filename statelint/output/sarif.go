@@ -0,0 +1,165 @@
+package output
+
+import (
+	"encoding/json"
+	"io"
+	"path/filepath"
+	"statelint/j2119"
+	"statelint/jsonsource"
+)
+
+const (
+	sarifVersion = "2.1.0"
+	sarifSchema  = "https://json.schemastore.org/sarif-2.1.0.json"
+)
+
+type sarifLog struct {
+	Schema  string     `json:"$schema"`
+	Version string     `json:"version"`
+	Runs    []sarifRun `json:"runs"`
+}
+
+type sarifRun struct {
+	Tool       sarifTool     `json:"tool"`
+	ColumnKind string        `json:"columnKind"`
+	Results    []sarifResult `json:"results"`
+}
+
+type sarifTool struct {
+	Driver sarifDriver `json:"driver"`
+}
+
+type sarifDriver struct {
+	Name  string      `json:"name"`
+	Rules []sarifRule `json:"rules"`
+}
+
+type sarifRule struct {
+	ID                   string             `json:"id"`
+	ShortDescription     sarifMessage       `json:"shortDescription"`
+	DefaultConfiguration sarifConfiguration `json:"defaultConfiguration"`
+}
+
+type sarifConfiguration struct {
+	Level string `json:"level"`
+}
+
+type sarifMessage struct {
+	Text string `json:"text"`
+}
+
+type sarifResult struct {
+	RuleID           string          `json:"ruleId"`
+	RuleIndex        *int            `json:"ruleIndex,omitempty"`
+	Level            string          `json:"level"`
+	Message          sarifMessage    `json:"message"`
+	Locations        []sarifLocation `json:"locations,omitempty"`
+	RelatedLocations []sarifLocation `json:"relatedLocations,omitempty"`
+}
+
+type sarifLocation struct {
+	ID               *int                   `json:"id,omitempty"`
+	PhysicalLocation *sarifPhysicalLocation `json:"physicalLocation,omitempty"`
+	LogicalLocations []sarifLogicalLocation `json:"logicalLocations,omitempty"`
+}
+
+type sarifPhysicalLocation struct {
+	ArtifactLocation sarifArtifactLocation `json:"artifactLocation"`
+	Region           *sarifRegion          `json:"region,omitempty"`
+}
+
+type sarifArtifactLocation struct {
+	URI string `json:"uri"`
+}
+
+type sarifRegion struct {
+	StartLine   int `json:"startLine"`
+	StartColumn int `json:"startColumn"`
+}
+
+type sarifLogicalLocation struct {
+	FullyQualifiedName string `json:"fullyQualifiedName"`
+	Kind               string `json:"kind"`
+}
+
+// writeSARIF prints a SARIF 2.1.0 log with one run. Every known rule is described in the driver.
+func writeSARIF(w io.Writer, file string, problems []j2119.Problem) error {
+	run := sarifRun{
+		Tool:       sarifTool{Driver: sarifDriver{Name: ToolName, Rules: make([]sarifRule, 0, len(j2119.Rules))}},
+		ColumnKind: "unicodeCodePoints",
+		Results:    make([]sarifResult, 0, len(problems)),
+	}
+
+	ruleIndexes := make(map[string]int)
+
+	for i, rule := range j2119.Rules {
+		ruleIndexes[rule.ID] = i
+		run.Tool.Driver.Rules = append(run.Tool.Driver.Rules, sarifRule{
+			ID:                   rule.ID,
+			ShortDescription:     sarifMessage{Text: rule.Description()},
+			DefaultConfiguration: sarifConfiguration{Level: sarifLevel(rule.Severity)},
+		})
+	}
+
+	for _, problem := range problems {
+		result := sarifResult{
+			RuleID:  problem.RuleID,
+			Level:   sarifLevel(problem.Severity),
+			Message: sarifMessage{Text: problem.Text},
+		}
+
+		if index, ok := ruleIndexes[problem.RuleID]; ok {
+			result.RuleIndex = &index
+		}
+
+		name := problemFile(file, problem)
+		location := sarifLocation{PhysicalLocation: newSARIFPhysicalLocation(name, problem.Position)}
+
+		if len(problem.Path) != 0 {
+			location.LogicalLocations = []sarifLogicalLocation{{
+				FullyQualifiedName: j2119.FormatPath(problem.Path),
+				Kind:               "member",
+			}}
+		}
+
+		if location.PhysicalLocation != nil || location.LogicalLocations != nil {
+			result.Locations = []sarifLocation{location}
+		}
+
+		for i, related := range problem.Related {
+			id := i
+			result.RelatedLocations = append(result.RelatedLocations, sarifLocation{
+				ID:               &id,
+				PhysicalLocation: newSARIFPhysicalLocation(name, related),
+			})
+		}
+
+		run.Results = append(run.Results, result)
+	}
+
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+
+	return encoder.Encode(sarifLog{Schema: sarifSchema, Version: sarifVersion, Runs: []sarifRun{run}})
+}
+
+func newSARIFPhysicalLocation(file string, position jsonsource.Position) *sarifPhysicalLocation {
+	if file == "" {
+		return nil
+	}
+
+	location := &sarifPhysicalLocation{ArtifactLocation: sarifArtifactLocation{URI: filepath.ToSlash(file)}}
+	if position.IsValid() {
+		location.Region = &sarifRegion{StartLine: position.Line, StartColumn: position.Column}
+	}
+
+	return location
+}
+
+func sarifLevel(severity j2119.Severity) string {
+	if severity == j2119.SeverityWarning {
+		return "warning"
+	}
+
+	return "error"
+}
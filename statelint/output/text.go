@@ -0,0 +1,33 @@
+package output
+
+import (
+	"fmt"
+	"io"
+	"statelint/j2119"
+	"statelint/localization"
+)
+
+// writeText prints the problem count and one problem per line prefixed with its location
+func writeText(w io.Writer, _ string, problems []j2119.Problem) error {
+	if len(problems) != 0 {
+		problemsCountStr := fmt.Sprintf(localization.
+			GetStringOrDefault("ProblemsCount", "There is %d errors:"),
+			len(problems))
+		if _, err := fmt.Fprintln(w, problemsCountStr); err != nil {
+			return err
+		}
+	}
+
+	for _, p := range problems {
+		line := p.Text
+		if location := p.Location(); location != "" {
+			line = location + ": " + line
+		}
+
+		if _, err := fmt.Fprintln(w, line); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
@@ -1,13 +1,13 @@
 package reader
 
 import (
-	"encoding/json"
 	"fmt"
 	"io"
 	"os"
+	"statelint/jsonsource"
 )
 
-func GetJSONFromLocalFile(path string) (interface{}, error) {
+func GetJSONFromLocalFile(path string) (*jsonsource.Value, error) {
 	file, err := os.Open(path)
 	if err != nil {
 		return nil, fmt.Errorf("can not open local file \"%s\": %w", path, err)
@@ -19,9 +19,7 @@ func GetJSONFromLocalFile(path string) (interface{}, error) {
 		return nil, fmt.Errorf("can not read local file \"%s\": %w", path, err)
 	}
 
-	var j interface{}
-
-	err = json.Unmarshal(data, &j)
+	j, err := jsonsource.Decode(data)
 	if err != nil {
 		return nil, fmt.Errorf("can not unmarshal local file \"%s\": %w", path, err)
 	}
@@ -2,12 +2,12 @@ package reader
 
 import (
 	"context"
-	"encoding/json"
 	"errors"
 	"fmt"
 	"io"
 	path2 "path"
 	"statelint/config"
+	"statelint/jsonsource"
 	"strings"
 
 	"github.com/minio/minio-go/v7"
@@ -22,7 +22,7 @@ const (
 	separator            = "/"
 )
 
-func GetJSONFromMinio(minioConfig config.Minio, minioPath string) (interface{}, error) {
+func GetJSONFromMinio(minioConfig config.Minio, minioPath string) (*jsonsource.Value, error) {
 	minioPath = path2.Clean(minioPath)
 	minioPath = strings.ReplaceAll(minioPath, "\\", "/")
 	parts := strings.Split(minioPath, separator)
@@ -58,9 +58,7 @@ func GetJSONFromMinio(minioConfig config.Minio, minioPath string) (interface{},
 		return nil, fmt.Errorf("can not read minio object: %w", err)
 	}
 
-	var j interface{}
-	err = json.Unmarshal(data, &j)
-
+	j, err := jsonsource.Decode(data)
 	if err != nil {
 		return nil, fmt.Errorf("can not unmarshal minio object: %w", err)
 	}